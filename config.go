@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/RaniSputnik/lovepac/packer"
+	"github.com/RaniSputnik/lovepac/packing"
+	"github.com/RaniSputnik/lovepac/target"
+)
+
+// DefaultConfigFile is the project file that is loaded
+// from the working directory if -config is not given
+const DefaultConfigFile = "lovepac.json"
+
+// job is a single invocation of the texture packer, it
+// is configured by the command line flags or a project file
+type job struct {
+	Name      string `json:"name"`
+	Separator string `json:"separator"`
+	Input     string `json:"input"`
+	// Include and Exclude filter the images in the input by their
+	// name in the same way as the patterns of rules, eg. "ui/*.png"
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	Output  string   `json:"output"`
+	Format  string   `json:"format"`
+	// Formats writes a descriptor in each of the formats, overrides Format
+	Formats        []string `json:"formats,omitempty"`
+	Width          int      `json:"width"`
+	Height         int      `json:"height"`
+	MaxTextureSize int      `json:"maxTextureSize"`
+	Padding        int      `json:"padding"`
+	BorderPadding  int      `json:"borderPadding"`
+	ShapePadding   int      `json:"shapePadding"`
+	Extrude        int      `json:"extrude"`
+	MaxAtlases     int      `json:"maxAtlases"`
+	Algorithm      string   `json:"algorithm"`
+	Sort           string   `json:"sort"`
+	Rotate         bool     `json:"rotate"`
+	Trim           bool     `json:"trim"`
+	Deduplicate    bool     `json:"deduplicate"`
+	PowerOfTwo     bool     `json:"powerOfTwo"`
+	Square         bool     `json:"square"`
+	SizeMultiple   int      `json:"sizeMultiple"`
+	Group          bool     `json:"group"`
+	Cache          string   `json:"cache"`
+	// Rules are applied before the rules read from RulesFile
+	Rules     []packer.Rule `json:"rules,omitempty"`
+	RulesFile string        `json:"rulesFile"`
+}
+
+// config is the contents of a project file, eg.
+//
+//	{
+//		"format": "love",
+//		"output": "build",
+//		"padding": 2,
+//		"jobs": [
+//			{ "name": "ui", "input": "assets/ui", "exclude": ["*.psd"] },
+//			{ "name": "tiles", "input": "assets/tiles", "extrude": 1 }
+//		]
+//	}
+//
+// The settings outside of the jobs are shared by every job.
+type config struct {
+	job
+	Jobs []json.RawMessage `json:"jobs"`
+}
+
+// jobFlags defines the command line flags that configure the job
+func jobFlags(fs *flag.FlagSet, j *job) {
+	fs.StringVar(&j.Name, "name", packer.DefaultAtlasName, "the base name of the output images and data files")
+	fs.StringVar(&j.Separator, "separator", packer.DefaultNameSeparator, "the separator between the directories of an image name in the descriptor")
+	fs.StringVar(&j.Output, "out", "", "the directory to output the result to")
+	fs.StringVar(&j.Format, "format", "love", "the export format of the atlas")
+	fs.IntVar(&j.Width, "width", packer.DefaultAtlasWidth, "maximum width of an atlas image, 0 indicates no maximum")
+	fs.IntVar(&j.Height, "height", packer.DefaultAtlasHeight, "maximum height of an atlas image, 0 indicates no maximum")
+	fs.IntVar(&j.MaxTextureSize, "maxtexturesize", packer.DefaultMaxTextureSize, "the largest width or height of any atlas image")
+	fs.IntVar(&j.Padding, "padding", 0, "the space between images and around the edge of the atlas")
+	fs.IntVar(&j.BorderPadding, "borderpadding", -1, "the space between images and the edge of the atlas, overrides padding unless -1")
+	fs.IntVar(&j.ShapePadding, "shapepadding", -1, "the space between neighbouring images, overrides padding unless -1")
+	fs.StringVar(&j.Algorithm, "algorithm", "binpack", fmt.Sprintf("the packing algorithm to use, one of: %s or %s to try them all", strings.Join(packing.Algorithms(), ", "), packing.AlgorithmBest))
+	fs.StringVar(&j.Sort, "sort", "area", fmt.Sprintf("the order to pack images in, one of: %s", strings.Join(packing.SortOrders(), ", ")))
+	fs.BoolVar(&j.Rotate, "rotate", false, "allow images to be rotated to pack them more tightly, the format must support rotation")
+	fs.BoolVar(&j.Trim, "trim", false, "remove transparent edges from images before packing, the format must support trimming")
+	fs.BoolVar(&j.Deduplicate, "deduplicate", false, "pack identical images once, every name is still written to the descriptor")
+	fs.IntVar(&j.Extrude, "extrude", 0, "the number of pixels to repeat the edges of each image by to prevent texture bleeding")
+	fs.BoolVar(&j.PowerOfTwo, "pot", false, "shrink each atlas to the smallest power of two width and height")
+	fs.BoolVar(&j.Square, "square", false, "shrink each atlas to the smallest square size")
+	fs.IntVar(&j.SizeMultiple, "multiple", 0, "round the width and height of each atlas up to a multiple of this number")
+	fs.BoolVar(&j.Group, "group", false, "pack the images in each top-level directory into their own atlases named after the directory")
+	fs.StringVar(&j.Cache, "cache", "", "a file to cache the result in, atlases are only rebuilt when the images or flags change")
+	fs.StringVar(&j.RulesFile, "rules", "", "a JSON file of rules that override the padding, extrusion, trimming and rotation of matching images")
+	fs.IntVar(&j.MaxAtlases, "maxatlases", 0, "the maximum number of atlases to write, 0 indicates no maximum")
+}
+
+// readConfig reads the jobs of the project file at the given path. Each job
+// starts with the default settings, then the settings of the project file
+// and finally the command line flags that were set are applied. The paths
+// in the project file are relative to the directory of the project file.
+func readConfig(filename string, flags *flag.FlagSet) ([]*job, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// The shared settings are read again for each job so
+	// that the jobs never share the same slices of patterns
+	shared := func() (config, error) {
+		var c config
+		jobFlags(flag.NewFlagSet("", flag.ContinueOnError), &c.job)
+		if err := decodeStrict(data, &c); err != nil {
+			return c, fmt.Errorf("Failed to read config '%s': %s", filename, err)
+		}
+		return c, nil
+	}
+	c, err := shared()
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Jobs) == 0 {
+		c.Jobs = []json.RawMessage{json.RawMessage("{}")}
+	}
+
+	var jobs []*job
+	for i, raw := range c.Jobs {
+		jobConfig, _ := shared()
+		j := jobConfig.job
+		if err := decodeStrict(raw, &j); err != nil {
+			return nil, fmt.Errorf("Failed to read job %d of config '%s': %s", i+1, filename, err)
+		}
+		j.resolvePaths(filepath.Dir(filename))
+		if err := overrideJob(&j, flags); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, &j)
+	}
+	return jobs, nil
+}
+
+// decodeStrict decodes the JSON data into v, failing
+// on any keys that v does not have, eg. misspelt settings
+func decodeStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// resolvePaths makes the relative paths of the job relative to the given directory
+func (j *job) resolvePaths(dir string) {
+	for _, path := range []*string{&j.Input, &j.Output, &j.Cache, &j.RulesFile} {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+}
+
+// setInput sets the input directory given on the command line. It can't
+// be used with a project file of several jobs as they would all pack it.
+func setInput(jobs []*job, input string) error {
+	if len(jobs) > 1 {
+		return fmt.Errorf("Can't pack '%s' with a config of %d jobs, set the input of each job in the config instead", input, len(jobs))
+	}
+	for _, j := range jobs {
+		j.Input = input
+	}
+	return nil
+}
+
+// overrideJob applies the flags that were set on the command line to the job
+func overrideJob(j *job, flags *flag.FlagSet) error {
+	jobFlagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	defaults := *j
+	jobFlags(jobFlagSet, j)
+	*j = defaults
+
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		if jobFlagSet.Lookup(f.Name) == nil || err != nil {
+			return
+		}
+		err = jobFlagSet.Set(f.Name, f.Value.String())
+		if f.Name == "format" {
+			j.Formats = nil
+		}
+	})
+	return err
+}
+
+// params returns the packer params for each format written by the job
+func (j *job) params(events func(packer.Event)) ([]*packer.Params, error) {
+	if j.Input == "" {
+		return nil, fmt.Errorf("Missing input directory for '%s'", j.Name)
+	}
+
+	tryAll := j.Algorithm == packing.AlgorithmBest
+	algorithm := packing.AlgorithmNamed(j.Algorithm)
+	if algorithm == nil && !tryAll {
+		return nil, fmt.Errorf("Unknown algorithm '%s'", j.Algorithm)
+	}
+
+	sortOrder := packing.SortOrderNamed(j.Sort)
+	if sortOrder == nil {
+		return nil, fmt.Errorf("Unknown sort order '%s'", j.Sort)
+	}
+
+	rules := j.Rules
+	if j.RulesFile != "" {
+		f, err := os.Open(j.RulesFile)
+		if err != nil {
+			return nil, err
+		}
+		fileRules, err := packer.ReadRules(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read rules '%s': %s", j.RulesFile, err)
+		}
+		rules = append(append([]packer.Rule{}, rules...), fileRules...)
+	}
+
+	for _, pattern := range append(append([]string{}, j.Include...), j.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("Invalid pattern '%s': %s", pattern, err)
+		}
+	}
+
+	formats := j.Formats
+	if len(formats) == 0 {
+		formats = []string{j.Format}
+	}
+
+	var result []*packer.Params
+	for _, name := range formats {
+		format := target.FormatNamed(name)
+		if format == target.Unknown {
+			return nil, fmt.Errorf("Unknown format '%s'", name)
+		}
+
+		var cache *packer.Cache
+		if j.Cache != "" {
+			// Functions and outputters can't be compared between runs so
+			// the algorithm, sort order and output are identified by name
+			output, err := filepath.Abs(j.Output)
+			if err != nil {
+				return nil, err
+			}
+			cache = &packer.Cache{Path: j.Cache, Key: strings.Join([]string{j.Algorithm, j.Sort, output}, " ")}
+			// Every format has its own cache, otherwise
+			// each format would invalidate the others
+			if len(formats) > 1 {
+				cache.Path += "." + name
+			}
+		}
+
+		result = append(result, &packer.Params{
+			Name:             j.Name,
+			NameSeparator:    j.Separator,
+			Input:            filterStream(packer.NewFileStream(j.Input), j.Include, j.Exclude),
+			Output:           packer.NewFileOutputter(j.Output),
+			Format:           format,
+			Width:            j.Width,
+			Height:           j.Height,
+			MaxTextureSize:   j.MaxTextureSize,
+			Padding:          j.Padding,
+			BorderPadding:    optionalInt(j.BorderPadding),
+			ShapePadding:     optionalInt(j.ShapePadding),
+			Extrude:          j.Extrude,
+			MaxAtlases:       j.MaxAtlases,
+			Algorithm:        algorithm,
+			Sort:             sortOrder,
+			TryAll:           tryAll,
+			Rotate:           j.Rotate,
+			Trim:             j.Trim,
+			Deduplicate:      j.Deduplicate,
+			PowerOfTwo:       j.PowerOfTwo,
+			Square:           j.Square,
+			SizeMultiple:     j.SizeMultiple,
+			GroupByDirectory: j.Group,
+			Events:           events,
+			Cache:            cache,
+			Rules:            rules,
+		})
+	}
+	return result, nil
+}
+
+// optionalInt returns a pointer to the value, or nil if it is negative
+func optionalInt(value int) *int {
+	if value < 0 {
+		return nil
+	}
+	return &value
+}
+
+// filterStream streams the assets whose names match any of the include
+// patterns, or every asset if there are none, and none of the exclude patterns
+func filterStream(input packer.AssetStreamer, include, exclude []string) packer.AssetStreamer {
+	if len(include) == 0 && len(exclude) == 0 {
+		return input
+	}
+	return packer.AssetStreamerFunc(func(ctx context.Context) (<-chan packer.Asset, <-chan error) {
+		assets, errc := input.AssetStream(ctx)
+		stream := make(chan packer.Asset)
+		go func() {
+			defer close(stream)
+			for asset := range assets {
+				name := asset.Asset()
+				if (len(include) > 0 && !matchesAny(include, name)) || matchesAny(exclude, name) {
+					continue
+				}
+				select {
+				case stream <- asset:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return stream, errc
+	})
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if packer.MatchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/RaniSputnik/lovepac/packer"
+)
+
+// readTestConfig writes the config to a file in a temporary
+// directory and reads it with the given command line arguments
+func readTestConfig(t *testing.T, config string, args ...string) (string, []*job, error) {
+	dir, err := ioutil.TempDir("", "lovepac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	filename := filepath.Join(dir, DefaultConfigFile)
+	if err := ioutil.WriteFile(filename, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := flag.NewFlagSet("lovepac", flag.ContinueOnError)
+	var cli job
+	jobFlags(flags, &cli)
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := readConfig(filename, flags)
+	return dir, jobs, err
+}
+
+func TestReadConfigAppliesSettingsInOrderOfPrecedence(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Config        string
+		Args          []string
+		ExpectedWidth int
+	}{
+		{
+			Name:          "Default",
+			Config:        `{ "jobs": [{ "input": "in" }] }`,
+			ExpectedWidth: packer.DefaultAtlasWidth,
+		},
+		{
+			Name:          "Shared",
+			Config:        `{ "width": 512, "jobs": [{ "input": "in" }] }`,
+			ExpectedWidth: 512,
+		},
+		{
+			Name:          "Job",
+			Config:        `{ "width": 512, "jobs": [{ "input": "in", "width": 256 }] }`,
+			ExpectedWidth: 256,
+		},
+		{
+			Name:          "Flag",
+			Config:        `{ "width": 512, "jobs": [{ "input": "in", "width": 256 }] }`,
+			Args:          []string{"-width", "128"},
+			ExpectedWidth: 128,
+		},
+		{
+			Name:          "FlagSetToTheDefault",
+			Config:        `{ "width": 512, "jobs": [{ "input": "in", "width": 256 }] }`,
+			Args:          []string{"-width", "2048"},
+			ExpectedWidth: 2048,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, jobs, err := readTestConfig(t, test.Config, test.Args...)
+			if err != nil {
+				t.Fatalf("Expected config to be read without error but got '%s'", err)
+			}
+			if len(jobs) != 1 {
+				t.Fatalf("Expected 1 job but got %d", len(jobs))
+			}
+			if got := jobs[0].Width; got != test.ExpectedWidth {
+				t.Errorf("Expected width %d but got %d", test.ExpectedWidth, got)
+			}
+		})
+	}
+}
+
+func TestReadConfigWithFormatFlagIgnoresFormats(t *testing.T) {
+	tests := []struct {
+		Name            string
+		Args            []string
+		ExpectedFormat  string
+		ExpectedFormats []string
+	}{
+		{Name: "NoFlag", ExpectedFormat: "love", ExpectedFormats: []string{"love", "starling"}},
+		{Name: "Flag", Args: []string{"-format", "starling"}, ExpectedFormat: "starling"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, jobs, err := readTestConfig(t, `{ "formats": ["love", "starling"], "jobs": [{ "input": "in" }] }`, test.Args...)
+			if err != nil {
+				t.Fatalf("Expected config to be read without error but got '%s'", err)
+			}
+			if got := jobs[0].Format; got != test.ExpectedFormat {
+				t.Errorf("Expected format '%s' but got '%s'", test.ExpectedFormat, got)
+			}
+			if got := jobs[0].Formats; !reflect.DeepEqual(got, test.ExpectedFormats) {
+				t.Errorf("Expected formats %v but got %v", test.ExpectedFormats, got)
+			}
+		})
+	}
+}
+
+func TestReadConfigResolvesPathsRelativeToTheConfig(t *testing.T) {
+	dir, jobs, err := readTestConfig(t,
+		`{ "input": "assets", "cache": "build/atlas.cache", "rulesFile": "rules.json", "output": "build" }`,
+		"-out", "elsewhere")
+	if err != nil {
+		t.Fatalf("Expected config to be read without error but got '%s'", err)
+	}
+
+	expected := job{
+		Input:     filepath.Join(dir, "assets"),
+		Cache:     filepath.Join(dir, "build", "atlas.cache"),
+		RulesFile: filepath.Join(dir, "rules.json"),
+		// Flags are relative to the working directory
+		Output: "elsewhere",
+	}
+	got := jobs[0]
+	if got.Input != expected.Input || got.Cache != expected.Cache ||
+		got.RulesFile != expected.RulesFile || got.Output != expected.Output {
+		t.Errorf("Expected paths %+v but got %+v", expected, *got)
+	}
+}
+
+func TestReadConfigWithUnknownSettingResultsInError(t *testing.T) {
+	for _, config := range []string{
+		`{ "extrdue": 2, "jobs": [{ "input": "in" }] }`,
+		`{ "jobs": [{ "input": "in", "extrdue": 2 }] }`,
+	} {
+		if _, _, err := readTestConfig(t, config); err == nil {
+			t.Errorf("Expected an error for the misspelt setting in %s but got nil", config)
+		}
+	}
+}
+
+func TestSetInputWithSeveralJobsResultsInError(t *testing.T) {
+	jobs := []*job{{Name: "ui"}, {Name: "tiles"}}
+	if err := setInput(jobs, "assets"); err == nil {
+		t.Errorf("Expected an error as every job would pack 'assets' but got nil")
+	}
+
+	single := []*job{{Name: "ui"}}
+	if err := setInput(single, "assets"); err != nil {
+		t.Fatalf("Expected the input of a single job to be set but got '%s'", err)
+	}
+	if single[0].Input != "assets" {
+		t.Errorf("Expected the input to be 'assets' but got '%s'", single[0].Input)
+	}
+}
+
+func TestFilterStream(t *testing.T) {
+	files := []string{"a.png", "b.psd", "ui/c.png", "tiles/d.png"}
+	tests := []struct {
+		Name     string
+		Include  []string
+		Exclude  []string
+		Expected []string
+	}{
+		{Name: "None", Expected: files},
+		{Name: "Include", Include: []string{"*.png"}, Expected: []string{"a.png", "ui/c.png", "tiles/d.png"}},
+		{Name: "Exclude", Exclude: []string{"ui/*"}, Expected: []string{"a.png", "b.psd", "tiles/d.png"}},
+		{Name: "Both", Include: []string{"*.png"}, Exclude: []string{"ui/*"}, Expected: []string{"a.png", "tiles/d.png"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			stream := filterStream(packer.NewFilenameStream(".", files...), test.Include, test.Exclude)
+			assets, errc := stream.AssetStream(context.Background())
+			var got []string
+			for asset := range assets {
+				got = append(got, asset.Asset())
+			}
+			if err := <-errc; err != nil {
+				t.Fatalf("Expected assets to be streamed without error but got '%s'", err)
+			}
+			if !reflect.DeepEqual(got, test.Expected) {
+				t.Errorf("Expected assets %v but got %v", test.Expected, got)
+			}
+		})
+	}
+}
@@ -7,10 +7,10 @@ import (
 	_ "image/gif"
 	_ "image/jpeg"
 	"runtime/pprof"
+	"strings"
 	"time"
 
 	"github.com/RaniSputnik/lovepac/packer"
-	"github.com/RaniSputnik/lovepac/target"
 
 	"log"
 	"os"
@@ -28,14 +28,12 @@ func main() {
 	}
 
 	// Set and parse the command line arguments
-	pName := flag.String("name", packer.DefaultAtlasName, "the base name of the output images and data files")
-	pOutputDir := flag.String("out", "", "the directory to output the result to")
-	pVerbose = flag.Bool("v", false, "use verbose logging")
-	pFormat := flag.String("format", "love", "the export format of the atlas")
-	pWidth := flag.Int("width", packer.DefaultAtlasWidth, "maximum width of an atlas image")
-	pHeight := flag.Int("height", packer.DefaultAtlasHeight, "maximum height of an atlas image")
-	pPadding := flag.Int("padding", 0, "the space between images in the atlas")
-	pMaxAtlases := flag.Int("maxatlases", 0, "the maximum number of atlases to write, 0 indicates no maximum")
+	var cli job
+	jobFlags(flag.CommandLine, &cli)
+	pConfig := flag.String("config", "", fmt.Sprintf("a JSON project file describing the images to pack, %s is used if it exists, flags override its settings", DefaultConfigFile))
+	pVerbose = flag.Bool("v", false, "use verbose logging, every step of the packing is logged with its timing")
+	pProgress := flag.Bool("progress", false, "draw a progress bar of the images packed when writing to a terminal")
+	pWatch := flag.Bool("watch", false, "keep running and pack the images again whenever they are added, changed or removed")
 	pCPUProfile := flag.String("cpuprofile", "", "write cpu profile to file")
 	pMemprofile := flag.String("memprofile", "", "write memory profile to file")
 
@@ -50,31 +48,66 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	// Get the input directory
+	configFile := *pConfig
+	if configFile == "" {
+		if _, err := os.Stat(DefaultConfigFile); err == nil {
+			configFile = DefaultConfigFile
+		}
+	}
+
+	jobs := []*job{&cli}
+	if configFile != "" {
+		var err error
+		if jobs, err = readConfig(configFile, flag.CommandLine); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Get the input directory, it is optional when the project file has one
 	args := flag.Args()
-	if len(args) < 1 {
+	if len(args) >= 1 {
+		if err := setInput(jobs, args[0]); err != nil {
+			log.Fatal(err)
+		}
+	} else if configFile == "" {
 		fmt.Fprintf(os.Stderr, "Too few arguments passed, missing input directory \n\n")
 		flag.Usage()
 		return
 	}
-	inputDir := args[0]
 
-	format := target.FormatNamed(*pFormat)
-	if format == target.Unknown {
-		log.Fatalf("Unknown format '%s'", *pFormat)
+	var allParams []*packer.Params
+	var inputDirs, outputDirs, caches []string
+	for _, j := range jobs {
+		params, err := j.params(reportEvents(*pVerbose, *pProgress))
+		if err != nil {
+			log.Fatal(err)
+		}
+		allParams = append(allParams, params...)
+		inputDirs = append(inputDirs, j.Input)
+		outputDirs = append(outputDirs, j.Output)
+		for _, p := range params {
+			if p.Cache != nil {
+				caches = append(caches, p.Cache.Path)
+			}
+		}
+	}
+
+	pack := func(ctx context.Context) error {
+		for _, params := range allParams {
+			if err := packer.Run(ctx, params); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if *pWatch {
+		// Don't pack again when the cache is written to the input
+		log.Fatal(watch(inputDirs, outputDirs, caches, pack))
 	}
 
 	stopTimer := startTimer("Texture packing")
-	err := packer.Run(context.Background(), &packer.Params{
-		Name:       *pName,
-		Input:      packer.NewFileStream(inputDir),
-		Output:     packer.NewFileOutputter(*pOutputDir),
-		Format:     format,
-		Width:      *pWidth,
-		Height:     *pHeight,
-		Padding:    *pPadding,
-		MaxAtlases: *pMaxAtlases,
-	})
+	err := pack(context.Background())
 	stopTimer()
 
 	if *pMemprofile != "" {
@@ -92,6 +125,39 @@ func main() {
 	}
 }
 
+// reportEvents returns a hook that logs every packing event when verbose,
+// otherwise it draws a progress bar if asked to and stderr is a terminal
+func reportEvents(verbose, progress bool) func(packer.Event) {
+	if verbose {
+		return func(e packer.Event) {
+			if e.Duration > 0 {
+				log.Printf("%s '%s' took %s", e.Kind, e.Name, e.Duration)
+			} else {
+				log.Printf("%s '%s'", e.Kind, e.Name)
+			}
+		}
+	}
+
+	if !progress {
+		return nil
+	}
+	if info, err := os.Stderr.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return func(e packer.Event) {
+		if e.Kind != packer.SpritePlaced || e.Total == 0 {
+			return
+		}
+		const width = 40
+		done := width * e.Count / e.Total
+		fmt.Fprintf(os.Stderr, "\r[%s%s] %d/%d images packed",
+			strings.Repeat("=", done), strings.Repeat(" ", width-done), e.Count, e.Total)
+		if e.Count == e.Total {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+}
+
 func startTimer(name string) func() {
 	start := time.Now()
 	return func() {
@@ -0,0 +1,84 @@
+package packer
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"sort"
+
+	"github.com/RaniSputnik/lovepac/packing"
+)
+
+// hashPixels returns a hash of the colour of every pixel within the
+// given rectangle of the image. Images with the same pixels will have
+// the same hash regardless of how they were encoded.
+func hashPixels(img image.Image, r image.Rectangle) string {
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok {
+		nrgba = image.NewNRGBA(r)
+		draw.Draw(nrgba, r, img, r.Min, draw.Src)
+	}
+
+	h := sha256.New()
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint32(size[0:4], uint32(r.Dx()))
+	binary.LittleEndian.PutUint32(size[4:8], uint32(r.Dy()))
+	h.Write(size)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		i := nrgba.PixOffset(r.Min.X, y)
+		h.Write(nrgba.Pix[i : i+4*r.Dx()])
+	}
+	return string(h.Sum(nil))
+}
+
+// deduplicate removes sprites that have the same pixels as
+// another sprite, adding them as an alias of that sprite instead
+func deduplicate(sprites []packing.Block) []packing.Block {
+	// Choose the same sprite to keep each time
+	// regardless of the order the sprites were read in
+	sorted := make([]packing.Block, len(sprites))
+	copy(sorted, sprites)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].(*sprite).path < sorted[j].(*sprite).path
+	})
+
+	unique := make([]packing.Block, 0, len(sorted))
+	byHash := map[string]*sprite{}
+	for _, block := range sorted {
+		spr := block.(*sprite)
+		key := dedupKey(spr)
+		if original, ok := byHash[key]; ok {
+			original.aliases = append(original.aliases, spr)
+			continue
+		}
+		byHash[key] = spr
+		unique = append(unique, spr)
+	}
+	return unique
+}
+
+// dedupKey identifies the sprites that can share a position in the atlas,
+// they must have the same pixels and be packed with the same settings
+func dedupKey(spr *sprite) string {
+	return fmt.Sprintf("%s %d %d %d %t", spr.hash, spr.extrude, spr.borderPadding, spr.shapePadding, spr.rotatable)
+}
+
+// expandAliases returns the sprites along with any of their aliases,
+// the aliases are given the same position as the sprite they alias
+func expandAliases(sprites []packing.Block) []packing.Block {
+	expanded := make([]packing.Block, 0, len(sprites))
+	for _, block := range sprites {
+		spr := block.(*sprite)
+		expanded = append(expanded, spr)
+		for _, alias := range spr.aliases {
+			alias.x, alias.y = spr.x, spr.y
+			alias.rotated = spr.rotated
+			alias.placed = spr.placed
+			alias.isAlias = true
+			expanded = append(expanded, alias)
+		}
+	}
+	return expanded
+}
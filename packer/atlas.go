@@ -6,6 +6,7 @@ import (
 	"image/png"
 	"io"
 	"text/template"
+	"time"
 
 	"github.com/RaniSputnik/lovepac/packing"
 )
@@ -22,13 +23,33 @@ type atlas struct {
 	Padding int
 }
 
+// bounds returns the width and height of the smallest area,
+// from the origin, that contains all of the sprites (and their extrusion)
+func (a *atlas) bounds() (int, int) {
+	w, h := 0, 0
+	for i := range a.Sprites {
+		spr := a.Sprites[i].(*sprite)
+		if right := spr.x + spr.Width() + spr.extrude; right > w {
+			w = right
+		}
+		if bottom := spr.y + spr.Height() + spr.extrude; bottom > h {
+			h = bottom
+		}
+	}
+	return w, h
+}
+
 func (a *atlas) CreateImage() (image.Image, error) {
 	img := image.NewNRGBA(image.Rect(0, 0, a.Width, a.Height))
 
 	// TODO run these draw steps in parallel
 	for i := range a.Sprites {
 		spr := a.Sprites[i].(*sprite)
-		rect := image.Rect(spr.x, spr.y, spr.x+spr.w, spr.y+spr.h)
+		if spr.isAlias {
+			// Already drawn by the sprite that it aliases
+			continue
+		}
+		rect := image.Rect(spr.x, spr.y, spr.x+spr.Width(), spr.y+spr.Height())
 
 		assetReader, err := spr.Asset.Reader()
 		if err != nil {
@@ -38,30 +59,49 @@ func (a *atlas) CreateImage() (image.Image, error) {
 		if err != nil {
 			return nil, fmt.Errorf("Failed to decode asset '%s': %s", spr.path, err)
 		}
+		if spr.Trimmed() {
+			sprImg = crop(sprImg, image.Rect(spr.offsetX, spr.offsetY, spr.offsetX+spr.w, spr.offsetY+spr.h))
+		}
+		if spr.rotated {
+			sprImg = rotateClockwise(sprImg)
+		}
 
-		fastDraw(img, rect, sprImg)
+		fastDraw(img, rect, sprImg, sprImg.Bounds().Min)
+		if spr.extrude > 0 {
+			extrude(img, rect, spr.extrude)
+		}
 	}
 
 	return img, nil
 }
 
-func (a *atlas) Output(outputter Outputter, descriptorTemplate *template.Template) error {
+func (a *atlas) Output(outputter Outputter, descriptorTemplate *template.Template, events *eventLog) error {
 	errc := make(chan error, 2)
 	go func() {
 		// Create and write the resulting image
-		errc <- withFile(outputter, a.ImageFilename, func(writer io.Writer) error {
+		start := time.Now()
+		err := withFile(outputter, a.ImageFilename, func(writer io.Writer) error {
 			img, err := a.CreateImage()
 			if err != nil {
 				return err
 			}
 			return png.Encode(writer, img)
 		})
+		if err == nil {
+			events.emit(AtlasImageEncoded, a.ImageFilename, time.Since(start))
+		}
+		errc <- err
 	}()
 	go func() {
 		// Create and write the file that describes the image
-		errc <- withFile(outputter, a.DescFilename, func(writer io.Writer) error {
+		start := time.Now()
+		err := withFile(outputter, a.DescFilename, func(writer io.Writer) error {
 			return descriptorTemplate.Execute(writer, a)
 		})
+		if err == nil {
+			events.emit(DescriptorWritten, a.DescFilename, time.Since(start))
+		}
+		errc <- err
 	}()
 	// Drain error channel
 	for i := 0; i < 2; i++ {
@@ -0,0 +1,391 @@
+package packer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"text/template"
+
+	"github.com/RaniSputnik/lovepac/packing"
+)
+
+// cacheVersion is recorded in the cache file, it must be
+// changed whenever the cache file or the output changes
+const cacheVersion = 1
+
+// Cache configures the incremental rebuilds performed by Run, see Params.
+//
+// The cache assumes that the files written to the Output by the previous
+// run still exist, it should be deleted if they are removed.
+type Cache struct {
+	// Path is the file that the cache is read from and written to,
+	// eg. "build/atlas.cache.json"
+	Path string
+	// Key identifies any params that can not be compared between runs,
+	// such as custom Algorithm and Sort functions and the Output. It must
+	// be changed whenever they are changed, eg. by including the name of
+	// the algorithm and the output directory.
+	Key string
+}
+
+// cacheFile is the contents of the cache, it records
+// the inputs and the result of the previous run
+type cacheFile struct {
+	Version int               `json:"version"`
+	Params  string            `json:"params"`
+	Assets  map[string]string `json:"assets"`
+	Atlases []cachedAtlas     `json:"atlases"`
+}
+
+type cachedAtlas struct {
+	Name          string         `json:"name"`
+	ImageFilename string         `json:"image"`
+	DescFilename  string         `json:"descriptor"`
+	Width         int            `json:"width"`
+	Height        int            `json:"height"`
+	Sprites       []cachedSprite `json:"sprites"`
+}
+
+// cachedSprite records everything that is needed to draw a sprite and
+// describe it without reading its size or laying out the atlas again
+type cachedSprite struct {
+	Path    string `json:"path"`
+	X       int    `json:"x"`
+	Y       int    `json:"y"`
+	W       int    `json:"w"`
+	H       int    `json:"h"`
+	Extrude int    `json:"extrude,omitempty"`
+	Rotated bool   `json:"rotated,omitempty"`
+	OffsetX int    `json:"offsetX,omitempty"`
+	OffsetY int    `json:"offsetY,omitempty"`
+	SourceW int    `json:"sourceW"`
+	SourceH int    `json:"sourceH"`
+	AliasOf string `json:"aliasOf,omitempty"`
+}
+
+// memoryAsset is an asset that has been read into memory
+// so that its contents can be hashed and then decoded
+type memoryAsset struct {
+	name, src string
+	data      []byte
+}
+
+func (a *memoryAsset) Asset() string  { return a.name }
+func (a *memoryAsset) source() string { return a.src }
+func (a *memoryAsset) Reader() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(a.data)), nil
+}
+
+// runCached performs the texture packing, skipping as much of
+// the work as it can using the cache of the previous run
+func runCached(ctx context.Context, params *Params, events *eventLog) (*Result, error) {
+	assets, err := readAssets(ctx, params.Input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every step reads the assets from memory rather than the input
+	memoryParams := *params
+	memoryParams.Input = newMemoryAssetStream(assets)
+	params = &memoryParams
+
+	current := &cacheFile{
+		Version: cacheVersion,
+		Params:  hashParams(params),
+		Assets:  map[string]string{},
+	}
+	assetsByName := map[string]*memoryAsset{}
+	for _, asset := range assets {
+		sum := sha256.Sum256(asset.data)
+		current.Assets[asset.name] = hex.EncodeToString(sum[:])
+		assetsByName[asset.name] = asset
+	}
+
+	if previous := loadCache(params.Cache.Path); previous != nil && previous.canReuse(current) {
+		atlases := previous.atlases(assetsByName, params.NameSeparator)
+		var changed []*memoryAsset
+		for name, hash := range current.Assets {
+			if previous.Assets[name] != hash {
+				changed = append(changed, assetsByName[name])
+			}
+		}
+		if len(changed) == 0 {
+			return newResult(atlases), nil
+		}
+		// Changes to the pixels of a deduplicated sprite can
+		// change which sprites are identical, so start again
+		if !params.Deduplicate {
+			ok, err := redraw(ctx, params, atlases, changed, events)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				current.Atlases = previous.Atlases
+				return newResult(atlases), saveCache(params.Cache.Path, current)
+			}
+		}
+	}
+
+	atlases, err := build(ctx, params, events)
+	if err != nil {
+		return nil, err
+	}
+	current.Atlases = cacheAtlases(atlases)
+	return newResult(atlases), saveCache(params.Cache.Path, current)
+}
+
+// redraw writes the atlases that contain the changed assets again without
+// laying them out. Returns false if the size of any of the changed sprites
+// has changed, in which case the atlases must be laid out again.
+func redraw(ctx context.Context, params *Params, atlases []*atlas, changed []*memoryAsset, events *eventLog) (bool, error) {
+	decoded, err := readAssetStream(ctx, newMemoryAssetStream(changed), params.spriteSettings(), params.Rules, nil)
+	if err != nil {
+		return false, err
+	}
+	changedSprites := map[string]*sprite{}
+	for _, block := range decoded {
+		spr := block.(*sprite)
+		changedSprites[spr.path] = spr
+	}
+
+	var changedAtlases []*atlas
+	for _, a := range atlases {
+		atlasChanged := false
+		for _, block := range a.Sprites {
+			spr := block.(*sprite)
+			updated, ok := changedSprites[spr.path]
+			if !ok {
+				continue
+			}
+			if updated.w != spr.w || updated.h != spr.h ||
+				updated.offsetX != spr.offsetX || updated.offsetY != spr.offsetY ||
+				updated.sourceW != spr.sourceW || updated.sourceH != spr.sourceH {
+				return false, nil
+			}
+			atlasChanged = true
+		}
+		if atlasChanged {
+			changedAtlases = append(changedAtlases, a)
+		}
+	}
+	return true, outputAtlases(ctx, changedAtlases, params, events)
+}
+
+// readAssets reads the contents of every asset into memory
+func readAssets(ctx context.Context, assetStream AssetStreamer) ([]*memoryAsset, error) {
+	ctx, cancelCtx := context.WithCancel(ctx)
+	defer cancelCtx()
+
+	assets, errc := assetStream.AssetStream(ctx)
+	var result []*memoryAsset
+	for asset := range assets {
+		data, err := readAll(asset)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read asset '%s': %s", asset.Asset(), err)
+		}
+		result = append(result, &memoryAsset{name: asset.Asset(), src: assetSource(asset), data: data})
+	}
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func readAll(asset Asset) ([]byte, error) {
+	r, err := asset.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// newMemoryAssetStream streams the given assets
+func newMemoryAssetStream(assets []*memoryAsset) AssetStreamer {
+	return AssetStreamerFunc(func(ctx context.Context) (<-chan Asset, <-chan error) {
+		stream := make(chan Asset)
+		errc := make(chan error, 1)
+		go func() {
+			defer close(stream)
+			defer close(errc)
+			for _, asset := range assets {
+				select {
+				case stream <- asset:
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				}
+			}
+		}()
+		return stream, errc
+	})
+}
+
+// hashParams returns a hash of every param that affects the output
+func hashParams(params *Params) string {
+	data, _ := json.Marshal(struct {
+		Name, NameSeparator                  string
+		Format, Template, Ext                string
+		Width, Height, MaxTextureSize        int
+		BorderPadding, ShapePadding, Extrude int
+		MaxAtlases, SizeMultiple             int
+		TryAll, Rotate, Trim, Deduplicate    bool
+		PowerOfTwo, Square, GroupByDirectory bool
+		Rules                                []Rule
+		Key                                  string
+	}{
+		params.Name, params.NameSeparator,
+		params.Format.Name, templateText(params.Format.Template), params.Format.Ext,
+		params.Width, params.Height, params.MaxTextureSize,
+		params.borderPadding(), params.shapePadding(), params.Extrude,
+		params.MaxAtlases, params.SizeMultiple,
+		params.TryAll, params.Rotate, params.Trim, params.Deduplicate,
+		params.PowerOfTwo, params.Square, params.GroupByDirectory,
+		params.Rules,
+		params.Cache.Key,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// templateText returns the text of the template and every template
+// associated with it, so that a changed template invalidates the cache
+func templateText(t *template.Template) string {
+	if t == nil {
+		return ""
+	}
+	var texts []string
+	for _, associated := range t.Templates() {
+		if associated.Tree != nil && associated.Tree.Root != nil {
+			texts = append(texts, associated.Name()+":"+associated.Tree.Root.String())
+		}
+	}
+	sort.Strings(texts)
+	return fmt.Sprint(texts)
+}
+
+// canReuse returns true if the layout of the cache can be reused for
+// the current run, the params and the names of the assets must match
+func (c *cacheFile) canReuse(current *cacheFile) bool {
+	if c.Version != current.Version || c.Params != current.Params || len(c.Assets) != len(current.Assets) {
+		return false
+	}
+	for name := range current.Assets {
+		if _, ok := c.Assets[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// atlases recreates the atlases recorded in the cache using the given assets
+func (c *cacheFile) atlases(assets map[string]*memoryAsset, separator string) []*atlas {
+	atlases := make([]*atlas, 0, len(c.Atlases))
+	for _, ca := range c.Atlases {
+		a := &atlas{
+			Name:          ca.Name,
+			ImageFilename: ca.ImageFilename,
+			DescFilename:  ca.DescFilename,
+			Width:         ca.Width,
+			Height:        ca.Height,
+			Sprites:       make([]packing.Block, 0, len(ca.Sprites)),
+		}
+		byPath := map[string]*sprite{}
+		for _, cs := range ca.Sprites {
+			spr := &sprite{
+				Asset:     assets[cs.Path],
+				path:      cs.Path,
+				separator: separator,
+				x:         cs.X,
+				y:         cs.Y,
+				w:         cs.W,
+				h:         cs.H,
+				extrude:   cs.Extrude,
+				rotated:   cs.Rotated,
+				placed:    true,
+				offsetX:   cs.OffsetX,
+				offsetY:   cs.OffsetY,
+				sourceW:   cs.SourceW,
+				sourceH:   cs.SourceH,
+			}
+			if original, ok := byPath[cs.AliasOf]; ok {
+				spr.isAlias = true
+				original.aliases = append(original.aliases, spr)
+			}
+			byPath[cs.Path] = spr
+			a.Sprites = append(a.Sprites, spr)
+		}
+		atlases = append(atlases, a)
+	}
+	return atlases
+}
+
+// cacheAtlases records the atlases so that they can be recreated
+func cacheAtlases(atlases []*atlas) []cachedAtlas {
+	cached := make([]cachedAtlas, 0, len(atlases))
+	for _, a := range atlases {
+		aliasOf := map[*sprite]string{}
+		ca := cachedAtlas{
+			Name:          a.Name,
+			ImageFilename: a.ImageFilename,
+			DescFilename:  a.DescFilename,
+			Width:         a.Width,
+			Height:        a.Height,
+			Sprites:       make([]cachedSprite, 0, len(a.Sprites)),
+		}
+		for _, block := range a.Sprites {
+			spr := block.(*sprite)
+			for _, alias := range spr.aliases {
+				aliasOf[alias] = spr.path
+			}
+			ca.Sprites = append(ca.Sprites, cachedSprite{
+				Path:    spr.path,
+				X:       spr.x,
+				Y:       spr.y,
+				W:       spr.w,
+				H:       spr.h,
+				Extrude: spr.extrude,
+				Rotated: spr.rotated,
+				OffsetX: spr.offsetX,
+				OffsetY: spr.offsetY,
+				SourceW: spr.sourceW,
+				SourceH: spr.sourceH,
+				AliasOf: aliasOf[spr],
+			})
+		}
+		cached = append(cached, ca)
+	}
+	return cached
+}
+
+// loadCache reads the cache file at the given path, returning
+// nil if the cache does not exist or can not be read
+func loadCache(path string) *cacheFile {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var c cacheFile
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil
+	}
+	return &c
+}
+
+// saveCache writes the cache file to the given path
+func saveCache(path string, c *cacheFile) error {
+	data, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("Failed to write cache '%s': %s", path, err)
+	}
+	return nil
+}
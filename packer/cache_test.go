@@ -0,0 +1,156 @@
+package packer_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"text/template"
+
+	"github.com/RaniSputnik/lovepac/packer"
+	"github.com/RaniSputnik/lovepac/target"
+)
+
+func TestRunWithCacheOnlyRebuildsWhatChanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lovepac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	red := color.NRGBA{255, 0, 0, 255}
+	blue := color.NRGBA{0, 0, 255, 255}
+	square := image.Rect(0, 0, 10, 10)
+
+	// Each sprite fills an atlas of its own
+	run := func(padding int, assets ...*bytesAsset) (*packer.Result, map[string]*bytes.Buffer) {
+		outputRecorder := NewOutputRecorder()
+		params := &packer.Params{
+			Format:  target.Love,
+			Input:   newBytesAssetStream(assets...),
+			Output:  outputRecorder,
+			Width:   10 + 2*padding,
+			Height:  10 + 2*padding,
+			Padding: padding,
+			Cache:   &packer.Cache{Path: filepath.Join(dir, "atlas.cache.json")},
+		}
+		result, err := packer.RunWithResult(context.Background(), params)
+		if err != nil {
+			t.Fatalf("Expected run to succeed without error but got '%s'", err)
+		}
+		return result, outputRecorder.Got()
+	}
+	filenames := func(got map[string]*bytes.Buffer) []string {
+		names := []string{}
+		for name := range got {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	firstResult, got := run(0,
+		newImageAsset(t, "a.png", 10, 10, square, red),
+		newImageAsset(t, "b.png", 10, 10, square, red),
+	)
+	if expected := []string{"atlas-1.lua", "atlas-1.png", "atlas-2.lua", "atlas-2.png"}; !reflect.DeepEqual(filenames(got), expected) {
+		t.Fatalf("Expected the first run to output %v but got %v", expected, filenames(got))
+	}
+
+	t.Run("Nothing is written when nothing has changed", func(t *testing.T) {
+		result, got := run(0,
+			newImageAsset(t, "a.png", 10, 10, square, red),
+			newImageAsset(t, "b.png", 10, 10, square, red),
+		)
+		if len(got) > 0 {
+			t.Errorf("Expected nothing to be outputted but got %v", filenames(got))
+		}
+		if !reflect.DeepEqual(result, firstResult) {
+			t.Errorf("Expected the result to be the same as the first run\n%+v\nbut got\n%+v", firstResult, result)
+		}
+	})
+
+	t.Run("Only the atlas with changed pixels is written", func(t *testing.T) {
+		result, got := run(0,
+			newImageAsset(t, "a.png", 10, 10, square, red),
+			newImageAsset(t, "b.png", 10, 10, square, blue),
+		)
+		if expected := []string{"atlas-2.lua", "atlas-2.png"}; !reflect.DeepEqual(filenames(got), expected) {
+			t.Fatalf("Expected %v to be outputted but got %v", expected, filenames(got))
+		}
+		if !reflect.DeepEqual(result, firstResult) {
+			t.Errorf("Expected the layout to be the same as the first run\n%+v\nbut got\n%+v", firstResult, result)
+		}
+		atlasImg, err := png.Decode(got["atlas-2.png"])
+		if err != nil {
+			t.Fatalf("Failed to decode atlas image: %s", err)
+		}
+		if gotColor := color.NRGBAModel.Convert(atlasImg.At(5, 5)); gotColor != blue {
+			t.Errorf("Expected the atlas to be redrawn with the new pixels but got %v", gotColor)
+		}
+	})
+
+	t.Run("Everything is written when a size changes", func(t *testing.T) {
+		_, got := run(0,
+			newImageAsset(t, "a.png", 10, 10, square, red),
+			newImageAsset(t, "b.png", 10, 8, image.Rect(0, 0, 10, 8), blue),
+		)
+		if len(got) != 4 {
+			t.Errorf("Expected every atlas to be outputted but got %v", filenames(got))
+		}
+	})
+
+	t.Run("Everything is written when the params change", func(t *testing.T) {
+		_, got := run(1,
+			newImageAsset(t, "a.png", 10, 10, square, red),
+			newImageAsset(t, "b.png", 10, 8, image.Rect(0, 0, 10, 8), blue),
+		)
+		if len(got) != 4 {
+			t.Errorf("Expected every atlas to be outputted but got %v", filenames(got))
+		}
+	})
+}
+
+func TestRunWithCacheRebuildsWhenTheTemplateChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lovepac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Both formats have the same name and extension
+	run := func(descriptor string) map[string]*bytes.Buffer {
+		outputRecorder := NewOutputRecorder()
+		params := &packer.Params{
+			Format: target.Format{
+				Name:     "custom",
+				Template: template.Must(template.New("custom").Parse(descriptor)),
+				Ext:      "txt",
+			},
+			Input:  newBytesAssetStream(newImageAsset(t, "a.png", 10, 10, image.Rect(0, 0, 10, 10), color.NRGBA{255, 0, 0, 255})),
+			Output: outputRecorder,
+			Cache:  &packer.Cache{Path: filepath.Join(dir, "atlas.cache.json")},
+		}
+		if err := packer.Run(context.Background(), params); err != nil {
+			t.Fatalf("Expected run to succeed without error but got '%s'", err)
+		}
+		return outputRecorder.Got()
+	}
+
+	run("{{ range .Sprites }}{{ .Name }}{{ end }}")
+	got := run("{{ range .Sprites }}{{ .Name }} {{ .Left }} {{ .Top }}{{ end }}")
+	descriptor, ok := got["atlas-1.txt"]
+	if !ok {
+		t.Fatalf("Expected the descriptor to be written with the new template but got %d files", len(got))
+	}
+	if expected := "a 0 0"; descriptor.String() != expected {
+		t.Errorf("Expected descriptor '%s' but got '%s'", expected, descriptor.String())
+	}
+}
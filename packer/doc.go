@@ -22,6 +22,17 @@ You can specify maximum width and height of an atlas to conform to
 platform limitations and you can build multiple atlases with a single
 command.
 
+RunWithResult can be used in place of Run to find out what was produced,
+eg. to write a build manifest, without parsing the descriptor files.
+
+	result, err := packer.RunWithResult(context.Background(), &params)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, atlas := range result.Atlases {
+		fmt.Printf("%s is %dx%d (%.1f%% used)\n", atlas.ImageFilename, atlas.Width, atlas.Height, atlas.Occupancy)
+	}
+
 The Input and Output parameters have been designed to be highly flexible.
 Simple file system readers and writers are provided out of the box but
 consumers could implement the AssetStreamer and Outputter interfaces to
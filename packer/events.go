@@ -0,0 +1,111 @@
+package packer
+
+import (
+	"sync"
+	"time"
+)
+
+// EventKind identifies the step of the texture packing that an Event reports
+type EventKind int
+
+const (
+	// AssetDiscovered is reported when an asset is read from the Input
+	AssetDiscovered EventKind = iota
+	// AssetDecoded is reported when the size (and pixels if required)
+	// of an asset have been decoded
+	AssetDecoded
+	// SpritePlaced is reported for every sprite the first time that it is
+	// placed in an atlas. The atlas may still be shrunk afterwards, which
+	// can move the sprite. When trying all algorithms (see Params.TryAll)
+	// it is reported once the best layout is known.
+	SpritePlaced
+	// AtlasStarted is reported when an atlas begins to be written
+	AtlasStarted
+	// AtlasImageEncoded is reported when the image of an atlas has been
+	// drawn, encoded and written to the Output
+	AtlasImageEncoded
+	// DescriptorWritten is reported when the descriptor
+	// of an atlas has been written to the Output
+	DescriptorWritten
+)
+
+var eventKindNames = [...]string{
+	AssetDiscovered:   "asset discovered",
+	AssetDecoded:      "asset decoded",
+	SpritePlaced:      "sprite placed",
+	AtlasStarted:      "atlas started",
+	AtlasImageEncoded: "atlas image encoded",
+	DescriptorWritten: "descriptor written",
+}
+
+func (k EventKind) String() string {
+	if k < 0 || int(k) >= len(eventKindNames) {
+		return "unknown"
+	}
+	return eventKindNames[k]
+}
+
+// Event reports progress made during texture packing, see Params.Events
+type Event struct {
+	Kind EventKind
+	// Name is the asset name for asset events, the sprite
+	// name for sprite events or the file name for atlas events
+	Name string
+	// Count is the number of events of this kind so far, including this
+	// one. Total is the number expected, or 0 if it is not yet known.
+	Count, Total int
+	// Duration is the time taken by the step being reported, or 0 for
+	// steps that are not timed. Elapsed is the time since packing began.
+	Duration time.Duration
+	Elapsed  time.Duration
+}
+
+// eventLog counts events and reports them to the events hook one at a time.
+// A nil eventLog discards all events.
+type eventLog struct {
+	mu     sync.Mutex
+	hook   func(Event)
+	start  time.Time
+	counts map[EventKind]int
+	totals map[EventKind]int
+}
+
+func newEventLog(hook func(Event)) *eventLog {
+	if hook == nil {
+		return nil
+	}
+	return &eventLog{
+		hook:   hook,
+		start:  time.Now(),
+		counts: map[EventKind]int{},
+		totals: map[EventKind]int{},
+	}
+}
+
+// expect sets the total number of events of the given kind
+func (l *eventLog) expect(kind EventKind, total int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.totals[kind] = total
+}
+
+// emit reports an event for the given step that took the given duration
+func (l *eventLog) emit(kind EventKind, name string, duration time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.counts[kind]++
+	l.hook(Event{
+		Kind:     kind,
+		Name:     name,
+		Count:    l.counts[kind],
+		Total:    l.totals[kind],
+		Duration: duration,
+		Elapsed:  time.Since(l.start),
+	})
+}
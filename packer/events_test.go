@@ -0,0 +1,159 @@
+package packer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RaniSputnik/lovepac/packer"
+	"github.com/RaniSputnik/lovepac/packing"
+	"github.com/RaniSputnik/lovepac/target"
+)
+
+func TestRunReportsEvents(t *testing.T) {
+	var events []packer.Event
+	params := &packer.Params{
+		Format: target.Love,
+		Input:  packer.NewFileStream("./fixtures"),
+		Output: NewOutputRecorder(),
+		// Events are never reported concurrently
+		Events: func(e packer.Event) { events = append(events, e) },
+	}
+
+	if err := packer.Run(context.Background(), params); err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	expectedCounts := map[packer.EventKind]int{
+		packer.AssetDiscovered:   5,
+		packer.AssetDecoded:      5,
+		packer.SpritePlaced:      5,
+		packer.AtlasStarted:      1,
+		packer.AtlasImageEncoded: 1,
+		packer.DescriptorWritten: 1,
+	}
+	expectedNames := map[packer.EventKind]string{
+		packer.AtlasStarted:      "atlas-1",
+		packer.AtlasImageEncoded: "atlas-1.png",
+		packer.DescriptorWritten: "atlas-1.lua",
+	}
+
+	counts := map[packer.EventKind]int{}
+	for i, e := range events {
+		counts[e.Kind]++
+		if e.Count != counts[e.Kind] {
+			t.Errorf("Expected %s event to have count %d but got %d", e.Kind, counts[e.Kind], e.Count)
+		}
+		if i > 0 && e.Elapsed < events[i-1].Elapsed {
+			t.Errorf("Expected the elapsed time of %s event to be after the previous event", e.Kind)
+		}
+		if name, ok := expectedNames[e.Kind]; ok && e.Name != name {
+			t.Errorf("Expected %s event to be named '%s' but got '%s'", e.Kind, name, e.Name)
+		}
+		// The number of sprites and atlases is known before they are reported
+		if e.Kind >= packer.SpritePlaced && e.Total != expectedCounts[e.Kind] {
+			t.Errorf("Expected %s event to have total %d but got %d", e.Kind, expectedCounts[e.Kind], e.Total)
+		}
+	}
+	for kind, expected := range expectedCounts {
+		if counts[kind] != expected {
+			t.Errorf("Expected %d %s events but got %d", expected, kind, counts[kind])
+		}
+	}
+
+	// Every sprite is placed before any atlas is written
+	lastPlaced, firstStarted := -1, len(events)
+	for i, e := range events {
+		if e.Kind == packer.SpritePlaced {
+			lastPlaced = i
+		}
+		if e.Kind == packer.AtlasStarted && i < firstStarted {
+			firstStarted = i
+		}
+	}
+	if lastPlaced > firstStarted {
+		t.Errorf("Expected every sprite to be placed before the atlas was started")
+	}
+}
+
+// placedCountingPacker records the number of sprites reported
+// as placed before each block is packed
+type placedCountingPacker struct {
+	packing.Packer
+	placed *int
+	seen   *[]int
+}
+
+func (p *placedCountingPacker) Pack(block packing.Block) error {
+	*p.seen = append(*p.seen, *p.placed)
+	return p.Packer.Pack(block)
+}
+
+func TestRunReportsSpritesAsTheyArePlaced(t *testing.T) {
+	placed := 0
+	var seen []int
+	params := &packer.Params{
+		Format: target.Love,
+		Input:  packer.NewFileStream("./fixtures"),
+		Output: NewOutputRecorder(),
+		Algorithm: func(width, height int) packing.Packer {
+			return &placedCountingPacker{Packer: packing.NewBinPacker(width, height), placed: &placed, seen: &seen}
+		},
+		Events: func(e packer.Event) {
+			if e.Kind == packer.SpritePlaced {
+				placed++
+			}
+		},
+	}
+
+	if err := packer.Run(context.Background(), params); err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	// The first pack places each of the 5 sprites in turn
+	if len(seen) < 5 {
+		t.Fatalf("Expected at least 5 sprites to be packed but got %d", len(seen))
+	}
+	for i, got := range seen[:5] {
+		if got != i {
+			t.Errorf("Expected %d sprites to be reported as placed before packing sprite %d but got %d", i, i+1, got)
+		}
+	}
+	if placed != 5 {
+		t.Errorf("Expected 5 sprites to be reported as placed but got %d", placed)
+	}
+}
+
+func TestRunTryAllReportsEverySpriteOnce(t *testing.T) {
+	placed := map[string]int{}
+	var totals []int
+	params := &packer.Params{
+		Format: target.Love,
+		Input:  packer.NewFileStream("./fixtures"),
+		Output: NewOutputRecorder(),
+		TryAll: true,
+		Events: func(e packer.Event) {
+			if e.Kind == packer.SpritePlaced {
+				placed[e.Name]++
+				totals = append(totals, e.Total)
+			}
+		},
+	}
+
+	if err := packer.Run(context.Background(), params); err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	if len(placed) != 5 {
+		t.Errorf("Expected 5 sprites to be reported as placed but got %d", len(placed))
+	}
+	for name, count := range placed {
+		if count != 1 {
+			t.Errorf("Expected sprite '%s' to be reported as placed once but got %d", name, count)
+		}
+	}
+	for _, total := range totals {
+		if total != 5 {
+			t.Errorf("Expected sprite placed event to have total 5 but got %d", total)
+		}
+	}
+}
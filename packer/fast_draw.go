@@ -5,12 +5,12 @@ import (
 	"image/draw"
 )
 
-func fastDraw(dst *image.NRGBA, r image.Rectangle, src image.Image) {
+func fastDraw(dst *image.NRGBA, r image.Rectangle, src image.Image, sp image.Point) {
 	switch srcType := src.(type) {
 	case *image.NRGBA:
-		drawCopySrc(dst, r, srcType, image.ZP)
+		drawCopySrc(dst, r, srcType, sp)
 	default:
-		draw.Draw(dst, r, src, image.ZP, draw.Src)
+		draw.Draw(dst, r, src, sp, draw.Src)
 	}
 }
 
@@ -39,3 +39,51 @@ func drawCopySrc(dst *image.NRGBA, r image.Rectangle, src *image.NRGBA, sp image
 		s0 += sdelta
 	}
 }
+
+// rotateClockwise returns a copy of the src image
+// rotated clockwise by 90 degrees
+func rotateClockwise(src image.Image) *image.NRGBA {
+	b := src.Bounds()
+	srcNRGBA, ok := src.(*image.NRGBA)
+	if !ok {
+		srcNRGBA = image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(srcNRGBA, srcNRGBA.Bounds(), src, b.Min, draw.Src)
+	}
+
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < h; y++ {
+		s0 := srcNRGBA.PixOffset(srcNRGBA.Rect.Min.X, srcNRGBA.Rect.Min.Y+y)
+		for x := 0; x < w; x++ {
+			// The top row of the source becomes the right column of the result
+			d0 := dst.PixOffset(h-1-y, x)
+			copy(dst.Pix[d0:d0+4], srcNRGBA.Pix[s0+4*x:s0+4*x+4])
+		}
+	}
+	return dst
+}
+
+// extrude copies the outermost rows and columns of pixels
+// within the rectangle r outwards by n pixels on every side
+func extrude(dst *image.NRGBA, r image.Rectangle, n int) {
+	// Extend each row left and right
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		left := dst.PixOffset(r.Min.X, y)
+		right := dst.PixOffset(r.Max.X-1, y)
+		for i := 1; i <= n; i++ {
+			copy(dst.Pix[left-4*i:left-4*i+4], dst.Pix[left:left+4])
+			copy(dst.Pix[right+4*i:right+4*i+4], dst.Pix[right:right+4])
+		}
+	}
+
+	// Then extend the (now wider) top and bottom rows up and down
+	rowStart, rowEnd := 4*(r.Min.X-n), 4*(r.Max.X+n)
+	top := dst.Pix[dst.PixOffset(0, r.Min.Y)+rowStart : dst.PixOffset(0, r.Min.Y)+rowEnd]
+	bottom := dst.Pix[dst.PixOffset(0, r.Max.Y-1)+rowStart : dst.PixOffset(0, r.Max.Y-1)+rowEnd]
+	for i := 1; i <= n; i++ {
+		above := dst.PixOffset(0, r.Min.Y-i)
+		below := dst.PixOffset(0, r.Max.Y-1+i)
+		copy(dst.Pix[above+rowStart:above+rowEnd], top)
+		copy(dst.Pix[below+rowStart:below+rowEnd], bottom)
+	}
+}
@@ -0,0 +1,125 @@
+package packer
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/RaniSputnik/lovepac/packing"
+)
+
+// directoryGroup returns the top-level directory of the asset
+// with the given name, or "" if the asset is not in a directory
+func directoryGroup(assetName string) string {
+	slashPath := filepath.ToSlash(assetName)
+	if i := strings.Index(slashPath, "/"); i >= 0 {
+		return slashPath[:i]
+	}
+	return ""
+}
+
+// groupSprites splits the sprites into the groups that they will be packed
+// in, returning the names of the groups in alphabetical order. Sprites that
+// are not in a group are packed in a group with the given default name.
+func groupSprites(sprites []packing.Block, defaultName string) ([]string, map[string][]packing.Block) {
+	groups := map[string][]packing.Block{}
+	for _, block := range sprites {
+		name := block.(*sprite).group
+		if name == "" {
+			name = defaultName
+		}
+		groups[name] = append(groups[name], block)
+	}
+	// An empty input still results in an (empty) atlas
+	if len(groups) == 0 {
+		groups[defaultName] = nil
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, groups
+}
+
+// layoutGroups arranges each group of sprites into its own atlases,
+// the atlases are named after the group. Groups are laid out concurrently.
+func layoutGroups(ctx context.Context, sprites []packing.Block, params *Params, events *eventLog) ([]*atlas, error) {
+	names, groups := groupSprites(sprites, params.Name)
+
+	results := make([][]*atlas, len(names))
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	wg.Add(len(names))
+	for i, name := range names {
+		go func(i int, name string) {
+			defer wg.Done()
+			groupParams := *params
+			groupParams.Name = name
+			results[i], errs[i] = layoutGroup(ctx, groups[name], &groupParams, events)
+		}(i, name)
+	}
+	wg.Wait()
+
+	var atlases []*atlas
+	for i := range names {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		atlases = append(atlases, results[i]...)
+	}
+	return atlases, nil
+}
+
+// layoutGroup arranges a single group of sprites into atlases
+func layoutGroup(ctx context.Context, sprites []packing.Block, params *Params, events *eventLog) ([]*atlas, error) {
+	if len(sprites) > 0 {
+		// The border is shared by every sprite in the atlas so the
+		// largest border padding of any of the sprites is used
+		border := 0
+		for _, block := range sprites {
+			border = max(border, block.(*sprite).borderPadding)
+		}
+		for _, block := range sprites {
+			block.(*sprite).borderPadding = border
+		}
+		params.BorderPadding = &border
+	}
+
+	if params.Deduplicate {
+		sprites = deduplicate(sprites)
+	}
+
+	var atlases []*atlas
+	var err error
+	if params.TryAll {
+		atlases, err = layoutBest(ctx, sprites, params)
+		if err != nil {
+			return nil, err
+		}
+		// Every candidate places its own copy of the
+		// sprites, so report those that were kept
+		for _, a := range atlases {
+			for _, block := range a.Sprites {
+				block.(*sprite).events = events
+				block.(*sprite).report()
+			}
+		}
+	} else {
+		for _, block := range sprites {
+			block.(*sprite).events = events
+		}
+		params.Sort(sprites)
+		atlases, err = layout(ctx, sprites, params, params.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, a := range atlases {
+		a.Sprites = expandAliases(a.Sprites)
+	}
+	return atlases, nil
+}
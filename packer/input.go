@@ -46,11 +46,25 @@ func (a *fileAsset) Asset() string {
 	return a.Name
 }
 
+func (a *fileAsset) source() string {
+	return a.path
+}
+
+// assetSource returns where the asset was read from, eg. the path of
+// a file, or the name of the asset if its source is not known
+func assetSource(asset Asset) string {
+	if s, ok := asset.(interface{ source() string }); ok {
+		return s.source()
+	}
+	return asset.Asset()
+}
+
 var errContextNil = errors.New("Context must not be nil")
 
 // NewFileStream creates an asset streamer that streams files from a given
-// input directory. The input directory will be walked and readers will be
-// created using the standard os package.
+// input directory. The input directory and all of its subdirectories will be
+// walked and readers will be created using the standard os package. Assets
+// are named by their path relative to the input directory, eg. "ui/button.png".
 func NewFileStream(inputDirectory string) AssetStreamer {
 	return AssetStreamerFunc(func(ctx context.Context) (<-chan Asset, <-chan error) {
 		stream := make(chan Asset)
@@ -70,7 +84,7 @@ func NewFileStream(inputDirectory string) AssetStreamer {
 					return err
 				}
 				if info.IsDir() {
-					// TODO walk nested directories...
+					// Walk will continue into the directory
 					return nil
 				}
 				if !info.Mode().IsRegular() {
@@ -1,7 +1,16 @@
 package packer_test
 
 import (
+	"bytes"
 	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"sync"
@@ -45,6 +54,34 @@ func TestFileStream(t *testing.T) {
 	})
 }
 
+func TestFileStreamWalksNestedDirectories(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lovepac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		"button.png",
+		filepath.Join("ui", "button.png"),
+		filepath.Join("hud", "bars", "health.png"),
+	}
+	expect := map[string]struct{}{}
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		expect[file] = struct{}{}
+	}
+
+	assetStreamer := packer.NewFileStream(dir)
+	testAssetStreamer(t, assetStreamer, expect)
+}
+
 func TestFilenameStream(t *testing.T) {
 	files := []string{
 		"button_active.png",
@@ -147,3 +184,48 @@ func testAssetStreamerReportsNilContext(t *testing.T, assetStream packer.AssetSt
 		t.Errorf("Expected 'context nil' error but got nil")
 	}
 }
+
+// In-memory AssetStreamer //
+// *********************** //
+
+type bytesAsset struct {
+	name string
+	data []byte
+}
+
+func (a *bytesAsset) Asset() string { return a.name }
+func (a *bytesAsset) Reader() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(a.data)), nil
+}
+
+// newImageAsset creates a png asset of the given size that is
+// transparent everywhere except for the given opaque rectangle
+func newImageAsset(t *testing.T, name string, w, h int, opaque image.Rectangle, c color.NRGBA) *bytesAsset {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, opaque, image.NewUniform(c), image.ZP, draw.Src)
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatalf("Failed to encode test image '%s': %s", name, err)
+	}
+	return &bytesAsset{name: name, data: buf.Bytes()}
+}
+
+func newBytesAssetStream(assets ...*bytesAsset) packer.AssetStreamer {
+	return packer.AssetStreamerFunc(func(ctx context.Context) (<-chan packer.Asset, <-chan error) {
+		stream := make(chan packer.Asset)
+		errc := make(chan error, 1)
+		go func() {
+			defer close(stream)
+			defer close(errc)
+			for _, asset := range assets {
+				select {
+				case stream <- asset:
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				}
+			}
+		}()
+		return stream, errc
+	})
+}
@@ -0,0 +1,98 @@
+package packer
+
+// Result describes the atlases outputted by RunWithResult
+type Result struct {
+	Atlases []AtlasResult
+}
+
+// AtlasResult describes a single atlas and the sprites packed into it
+type AtlasResult struct {
+	Name          string
+	ImageFilename string
+	DescFilename  string
+	Width, Height int
+	// Occupancy is the percentage (0-100) of the
+	// area of the atlas that is covered by sprites
+	Occupancy float64
+	Sprites   []SpriteResult
+}
+
+// SpriteResult describes where a sprite was placed within an atlas.
+// X, Y, Width and Height are the area of the atlas that the sprite covers,
+// Width and Height are swapped if the sprite was rotated.
+type SpriteResult struct {
+	Name  string
+	Asset string
+
+	X, Y          int
+	Width, Height int
+	Rotated       bool
+
+	// Trimmed is true if transparent edges were removed from the sprite,
+	// OffsetX and OffsetY are the position of the trimmed sprite within
+	// the original image of size SourceWidth x SourceHeight
+	Trimmed                   bool
+	OffsetX, OffsetY          int
+	SourceWidth, SourceHeight int
+
+	// AliasOf is the name of the sprite with identical pixels
+	// whose position this sprite shares, if any
+	AliasOf string
+}
+
+// newResult describes the given atlases
+func newResult(atlases []*atlas) *Result {
+	result := &Result{Atlases: make([]AtlasResult, 0, len(atlases))}
+	for _, a := range atlases {
+		result.Atlases = append(result.Atlases, newAtlasResult(a))
+	}
+	return result
+}
+
+func newAtlasResult(a *atlas) AtlasResult {
+	aliasOf := map[*sprite]string{}
+	for i := range a.Sprites {
+		spr := a.Sprites[i].(*sprite)
+		for _, alias := range spr.aliases {
+			aliasOf[alias] = spr.Name()
+		}
+	}
+
+	usedArea := 0
+	sprites := make([]SpriteResult, 0, len(a.Sprites))
+	for i := range a.Sprites {
+		spr := a.Sprites[i].(*sprite)
+		if !spr.isAlias {
+			usedArea += spr.w * spr.h
+		}
+		sprites = append(sprites, SpriteResult{
+			Name:         spr.Name(),
+			Asset:        spr.path,
+			X:            spr.x,
+			Y:            spr.y,
+			Width:        spr.Width(),
+			Height:       spr.Height(),
+			Rotated:      spr.rotated,
+			Trimmed:      spr.Trimmed(),
+			OffsetX:      spr.offsetX,
+			OffsetY:      spr.offsetY,
+			SourceWidth:  spr.sourceW,
+			SourceHeight: spr.sourceH,
+			AliasOf:      aliasOf[spr],
+		})
+	}
+
+	occupancy := 0.0
+	if area := a.Width * a.Height; area > 0 {
+		occupancy = 100 * float64(usedArea) / float64(area)
+	}
+	return AtlasResult{
+		Name:          a.Name,
+		ImageFilename: a.ImageFilename,
+		DescFilename:  a.DescFilename,
+		Width:         a.Width,
+		Height:        a.Height,
+		Occupancy:     occupancy,
+		Sprites:       sprites,
+	}
+}
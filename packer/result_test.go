@@ -0,0 +1,87 @@
+package packer_test
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/RaniSputnik/lovepac/packer"
+	"github.com/RaniSputnik/lovepac/target"
+)
+
+func TestRunWithResultDescribesTheAtlases(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Starling,
+		Input: newBytesAssetStream(
+			newImageAsset(t, "wide.png", 20, 10, image.Rect(0, 0, 20, 10), red),
+			newImageAsset(t, "a.png", 10, 10, image.Rect(2, 2, 8, 8), red),
+			newImageAsset(t, "b.png", 10, 10, image.Rect(2, 2, 8, 8), red),
+		),
+		Output:      outputRecorder,
+		Trim:        true,
+		Deduplicate: true,
+	}
+
+	result, err := packer.RunWithResult(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+	if len(result.Atlases) != 1 {
+		t.Fatalf("Expected 1 atlas in the result but got %d", len(result.Atlases))
+	}
+
+	atlas := result.Atlases[0]
+	if atlas.Name != "atlas-1" {
+		t.Errorf("Expected atlas to be named 'atlas-1' but got '%s'", atlas.Name)
+	}
+	for _, filename := range []string{atlas.ImageFilename, atlas.DescFilename} {
+		if _, ok := got[filename]; !ok {
+			t.Errorf("Expected result file '%s' to be outputted", filename)
+		}
+	}
+	atlasImg, err := png.Decode(got[atlas.ImageFilename])
+	if err != nil {
+		t.Fatalf("Failed to decode atlas image: %s", err)
+	}
+	if size := atlasImg.Bounds().Size(); atlas.Width != size.X || atlas.Height != size.Y {
+		t.Errorf("Expected atlas to be %dx%d but got %dx%d", size.X, size.Y, atlas.Width, atlas.Height)
+	}
+	// The duplicate sprite does not take up any more room
+	expectedOccupancy := 100 * float64(20*10+6*6) / float64(atlas.Width*atlas.Height)
+	if math.Abs(atlas.Occupancy-expectedOccupancy) > 0.001 {
+		t.Errorf("Expected occupancy to be %.2f%% but got %.2f%%", expectedOccupancy, atlas.Occupancy)
+	}
+
+	sprites := map[string]packer.SpriteResult{}
+	for _, spr := range atlas.Sprites {
+		sprites[spr.Name] = spr
+	}
+	if len(sprites) != 3 {
+		t.Fatalf("Expected 3 sprites in the result but got %d", len(sprites))
+	}
+
+	a, b := sprites["a"], sprites["b"]
+	if a.Asset != "a.png" || a.AliasOf != "" {
+		t.Errorf("Expected sprite 'a' to be from 'a.png' and not an alias but got %+v", a)
+	}
+	if !a.Trimmed || a.OffsetX != 2 || a.OffsetY != 2 || a.SourceWidth != 10 || a.SourceHeight != 10 {
+		t.Errorf("Expected sprite 'a' to be trimmed by 2 pixels from a 10x10 image but got %+v", a)
+	}
+	if a.Width != 6 || a.Height != 6 {
+		t.Errorf("Expected sprite 'a' to be 6x6 but got %dx%d", a.Width, a.Height)
+	}
+	if b.AliasOf != "a" || b.X != a.X || b.Y != a.Y {
+		t.Errorf("Expected sprite 'b' to be an alias of 'a' at the same position but got %+v", b)
+	}
+	if wide := sprites["wide"]; wide.Trimmed || wide.Rotated || wide.Width != 20 || wide.Height != 10 {
+		t.Errorf("Expected sprite 'wide' to be 20x10, untrimmed and unrotated but got %+v", wide)
+	}
+}
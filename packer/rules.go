@@ -0,0 +1,120 @@
+package packer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Rule overrides how the assets matching Pattern are packed.
+// Only the settings that are set (non-nil or non-empty) are overridden,
+// all other settings are taken from the Params.
+//
+// Pattern is matched against the name of each asset using the syntax of
+// path.Match, eg. "tiles/*.png" or "icon_*". A pattern without a "/" is
+// also matched against the file name alone, so "icon_*" matches "ui/icon_1".
+type Rule struct {
+	Pattern string `json:"pattern"`
+
+	ShapePadding *int  `json:"shapePadding,omitempty"`
+	Extrude      *int  `json:"extrude,omitempty"`
+	Trim         *bool `json:"trim,omitempty"`
+	Rotate       *bool `json:"rotate,omitempty"`
+
+	// BorderPadding is shared by every sprite in an atlas, the largest
+	// border padding of the sprites in an atlas is used. It is best
+	// combined with Group to give a group of assets its own border.
+	BorderPadding *int `json:"borderPadding,omitempty"`
+
+	// Group packs the matching assets into their own atlases
+	// named after the group, see Params.GroupByDirectory
+	Group string `json:"group,omitempty"`
+}
+
+// ReadRules reads a list of rules from JSON, eg.
+//
+//	[
+//		{ "pattern": "tiles/*", "extrude": 2 },
+//		{ "pattern": "icon_*.png", "shapePadding": 0, "trim": true }
+//	]
+func ReadRules(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, err
+	}
+	if err := validateRules(rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// validateRules returns an error if any of the rules has an invalid pattern
+// or a group that can't be used to name files
+func validateRules(rules []Rule) error {
+	for _, rule := range rules {
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			return fmt.Errorf("Invalid rule pattern '%s': %s", rule.Pattern, err)
+		}
+		if strings.ContainsAny(rule.Group, `/\`) {
+			return fmt.Errorf("Invalid rule group '%s': groups must not contain a path separator", rule.Group)
+		}
+	}
+	return nil
+}
+
+// matches returns true if the rule applies to the asset with the given name
+func (r Rule) matches(assetName string) bool {
+	return MatchPattern(r.Pattern, assetName)
+}
+
+// MatchPattern returns true if the asset with the given name matches the
+// pattern, patterns are matched in the same way as the Pattern of a Rule
+func MatchPattern(pattern, assetName string) bool {
+	slashName := filepath.ToSlash(assetName)
+	if ok, _ := path.Match(pattern, slashName); ok {
+		return true
+	}
+	if strings.Contains(pattern, "/") {
+		return false
+	}
+	ok, _ := path.Match(pattern, path.Base(slashName))
+	return ok
+}
+
+// apply returns the settings with the overrides of the rule applied
+func (r Rule) apply(settings spriteSettings) spriteSettings {
+	if r.BorderPadding != nil {
+		settings.borderPadding = *r.BorderPadding
+	}
+	if r.ShapePadding != nil {
+		settings.shapePadding = *r.ShapePadding
+	}
+	if r.Extrude != nil {
+		settings.extrude = *r.Extrude
+	}
+	if r.Trim != nil {
+		settings.trim = *r.Trim
+	}
+	if r.Rotate != nil {
+		settings.rotate = *r.Rotate
+	}
+	if r.Group != "" {
+		settings.group = r.Group
+	}
+	return settings
+}
+
+// settingsFor returns the settings for the asset with the given name,
+// every matching rule is applied in order so later rules take precedence
+func settingsFor(assetName string, defaults spriteSettings, rules []Rule) spriteSettings {
+	settings := defaults
+	for _, rule := range rules {
+		if rule.matches(assetName) {
+			settings = rule.apply(settings)
+		}
+	}
+	return settings
+}
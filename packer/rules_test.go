@@ -0,0 +1,52 @@
+package packer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RaniSputnik/lovepac/packer"
+)
+
+func TestReadRules(t *testing.T) {
+	rules, err := packer.ReadRules(strings.NewReader(`[
+		{ "pattern": "tiles/*", "extrude": 2, "borderPadding": 1 },
+		{ "pattern": "icon_*.png", "shapePadding": 0, "trim": true }
+	]`))
+	if err != nil {
+		t.Fatalf("Expected rules to be read without error but got '%s'", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("Expected 2 rules but got %d", len(rules))
+	}
+
+	tiles, icons := rules[0], rules[1]
+	if tiles.Pattern != "tiles/*" || tiles.Extrude == nil || *tiles.Extrude != 2 {
+		t.Errorf("Expected the first rule to extrude tiles by 2 but got %+v", tiles)
+	}
+	if tiles.BorderPadding == nil || *tiles.BorderPadding != 1 {
+		t.Errorf("Expected the first rule to set the border padding to 1 but got %+v", tiles)
+	}
+	if tiles.ShapePadding != nil || tiles.Trim != nil || tiles.Rotate != nil {
+		t.Errorf("Expected the first rule to leave all other settings unset but got %+v", tiles)
+	}
+	if icons.ShapePadding == nil || *icons.ShapePadding != 0 {
+		t.Errorf("Expected the second rule to set the shape padding to 0 but got %+v", icons)
+	}
+	if icons.Trim == nil || !*icons.Trim {
+		t.Errorf("Expected the second rule to trim icons but got %+v", icons)
+	}
+}
+
+func TestReadRulesWithAPathSeparatorInAGroupResultsInError(t *testing.T) {
+	_, err := packer.ReadRules(strings.NewReader(`[{ "pattern": "tiles/*", "group": "levels/tiles" }]`))
+	if err == nil {
+		t.Errorf("Expected an error for the group containing '/' but got nil")
+	}
+}
+
+func TestReadRulesWithInvalidPatternResultsInError(t *testing.T) {
+	_, err := packer.ReadRules(strings.NewReader(`[{ "pattern": "tiles/[" }]`))
+	if err == nil {
+		t.Errorf("Expected an error for the invalid pattern but got nil")
+	}
+}
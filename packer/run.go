@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"image"
+	"io"
+	"runtime"
 	"sort"
-
 	"sync"
+	"time"
 
 	"github.com/RaniSputnik/lovepac/packing"
 	"github.com/RaniSputnik/lovepac/target"
@@ -17,23 +19,49 @@ var (
 	// DefaultAtlasName is the default base name for
 	// outputted files when no name is provided
 	DefaultAtlasName = "atlas"
-	// DefaultAtlasWidth is the width used if no width is specified
+	// DefaultAtlasWidth is a typical maximum width for an atlas,
+	// a Width of 0 is unbounded, see DefaultMaxTextureSize
 	DefaultAtlasWidth = 2048
-	// DefaultAtlasHeight is the height used if no height is specified
+	// DefaultAtlasHeight is a typical maximum height for an atlas,
+	// a Height of 0 is unbounded, see DefaultMaxTextureSize
 	DefaultAtlasHeight = 2048
+	// DefaultNameSeparator is the separator used between the
+	// directories of a sprite name if no separator is specified
+	DefaultNameSeparator = "/"
+	// DefaultMaxTextureSize is the largest width or height
+	// of an atlas if no maximum texture size is specified
+	DefaultMaxTextureSize = 8192
 )
 
 // Params are passed to the packer.Run to configure the texture packing.
 // Input, Output and Format are required, all other options will use
 // sensible defaults if not explicitly provided.
 type Params struct {
-	Name          string
-	Input         AssetStreamer
-	Output        Outputter
-	Format        target.Format
-	Width, Height int
-	Padding       int
-	MaxAtlases    int
+	Name             string
+	NameSeparator    string
+	Input            AssetStreamer
+	Output           Outputter
+	Format           target.Format
+	Width, Height    int
+	MaxTextureSize   int
+	Padding          int
+	BorderPadding    *int
+	ShapePadding     *int
+	Extrude          int
+	MaxAtlases       int
+	Algorithm        packing.Factory
+	Sort             packing.SortOrder
+	TryAll           bool
+	Rotate           bool
+	Trim             bool
+	Deduplicate      bool
+	GroupByDirectory bool
+	PowerOfTwo       bool
+	Square           bool
+	SizeMultiple     int
+	Rules            []Rule
+	Events           func(Event)
+	Cache            *Cache
 }
 
 // applySensibleDefaults will fill in nil values with values
@@ -42,11 +70,21 @@ func (p *Params) applySensibleDefaults() {
 	if p.Name == "" {
 		p.Name = DefaultAtlasName
 	}
-	if p.Width == 0 {
-		p.Width = DefaultAtlasWidth
+	if p.NameSeparator == "" {
+		p.NameSeparator = DefaultNameSeparator
+	}
+	if p.MaxTextureSize == 0 {
+		p.MaxTextureSize = DefaultMaxTextureSize
+	}
+	if p.Algorithm == nil {
+		p.Algorithm = func(width, height int) packing.Packer {
+			return packing.NewBinPacker(width, height)
+		}
 	}
-	if p.Height == 0 {
-		p.Height = DefaultAtlasHeight
+	if p.Sort == nil {
+		p.Sort = func(blocks []packing.Block) {
+			sort.Stable(packing.ByArea(blocks))
+		}
 	}
 }
 
@@ -72,12 +110,19 @@ func (p *Params) validateRequiredParameters() error {
 // Params are provided to the Run method to configure
 // the texture packing output. Input, Ouput and Format parameters are
 // required all other parameters are optional. You can use the public
-// 'Default' properties to configure the defaults used when parameters
-// are missing.
+// 'Default' properties to configure the defaults used when the name,
+// name separator or maximum texture size are missing. A missing Width
+// or Height is unbounded, DefaultAtlasWidth and DefaultAtlasHeight are
+// typical values to set them to.
 //
 // Name is the name that will be prepended to the atlas files
 // outputted. Eg. a value of "myatlas" would result in "myatlas-1.png"
 //
+// NameSeparator joins the directories of each sprite name. Sprites are named
+// by the path of their asset without the extension, eg. "ui/button.png" is
+// named "ui/button", or "ui_button" with a separator of "_". Run fails if two
+// sprites have the same name.
+//
 // Input is used to provide readers for the assets that will be packed.
 // In most cases packer.NewFileStream can be used to read from the local
 // filesystem, but you could write an input that reads from a server, network
@@ -93,19 +138,113 @@ func (p *Params) validateRequiredParameters() error {
 // a valid template and file extension format, all other settings are optional.
 //
 // Width and Height configure the maximum size of the atlases outputted.
-// TODO 0 should be interpreted as no maxumum size.
+// A value of 0 is interpreted as no maximum size, the smallest atlas that
+// holds all of the sprites is found instead. MaxTextureSize limits the width
+// and height of every atlas regardless, which prevents an unbounded atlas
+// from growing larger than any GPU can load.
+// Each atlas is cropped to the area used by its sprites (and the border
+// padding), optionally rounded up to a multiple of SizeMultiple.
+//
+// PowerOfTwo and Square instead shrink each atlas to the smallest size that
+// holds its sprites with a width and height that are powers of two and/or
+// equal. The atlases will be no larger than the largest such size that fits
+// within Width and Height.
+//
+// BorderPadding is the space left between the sprites and the edges of
+// the atlas. ShapePadding is the space left between neighbouring sprites.
+// Padding is a shorthand that is used for either of BorderPadding or
+// ShapePadding when they are nil, so a pointer to 0 removes one of them.
+//
+// Extrude repeats the outermost pixels of each sprite outwards by the
+// given number of pixels to prevent neighbouring pixels bleeding into
+// the sprite when the atlas is filtered. Extrusion is separate from, and
+// in addition to, padding.
 //
 // MaxAtlases can be used to limit the number of atlases outputted. A value
 // of 0 is interpreted as no limit.
+//
+// Algorithm creates the packer used to arrange the sprites within each
+// atlas, it is called once per atlas with the width and height of the atlas.
+// Any of the registered algorithms can be used (see packing.AlgorithmNamed)
+// or a custom packer can be supplied. If no algorithm is given a
+// packing.BinPacker is used.
+//
+// Sort arranges the sprites into the order that they are packed in,
+// see packing.SortOrderNamed for the available orders. Sprites are
+// sorted by area (largest first) if no sort order is given. Sprites
+// are given to Sort in order of their asset names, a stable sort
+// ensures that the atlases are identical each time they are packed.
+//
+// TryAll packs the sprites with every registered algorithm and every
+// sort order, keeping whichever result uses the fewest atlases and then
+// the least total area. Algorithm and Sort are ignored when TryAll is set.
+// This is much slower but can save whole atlases.
+//
+// Rotate allows sprites to be rotated clockwise by 90 degrees when that
+// allows them to be packed more tightly. The Format must support rotation.
+//
+// Trim removes fully transparent rows and columns from the edges of each
+// sprite before it is packed. The offset and original size of each sprite
+// are recorded so that it can be restored. The Format must support trimming.
+//
+// Deduplicate packs sprites that have identical pixels (after trimming)
+// only once. Every sprite is still written to the descriptor, identical
+// sprites share the same position in the atlas.
+//
+// GroupByDirectory packs the sprites of each top-level directory of the
+// input into their own atlases, named after the directory. Eg. sprites in
+// "ui/" and "characters/" result in "ui-1.png", "characters-1.png" and
+// "characters-2.png". Sprites that are not in a directory use Name, Run
+// fails if a group has the same name.
+// MaxAtlases applies to each group separately. Groups are packed
+// concurrently.
+//
+// Rules override the padding, Extrude, Trim, Rotate and group settings
+// for the assets that match them, eg. tiles that need extruding alongside
+// icons that do not. When several rules match an asset the later rules
+// take precedence, see Rule and ReadRules.
+//
+// Events is called as each step of the texture packing is completed, eg.
+// to report progress. It is called from many goroutines but never more than
+// once at a time, so it should return quickly. See Event for the steps that
+// are reported.
+//
+// Cache enables incremental rebuilds. The content of every asset and the
+// params are compared with those recorded by the previous run, nothing is
+// written if nothing has changed. If only the pixels of some sprites have
+// changed (and not their sizes) only the atlases containing those sprites
+// are written again. See Cache.
 func Run(ctx context.Context, params *Params) error {
+	_, err := RunWithResult(ctx, params)
+	return err
+}
+
+// RunWithResult performs the texture packing in the same way as Run and
+// returns a Result describing every atlas that was outputted, including
+// the size and occupancy of each atlas and where each sprite was placed.
+func RunWithResult(ctx context.Context, params *Params) (*Result, error) {
 	if ctx == nil {
-		return errors.New("Context must not be nil")
+		return nil, errors.New("Context must not be nil")
 	}
 	if params == nil {
-		return errors.New("Params must not be nil")
+		return nil, errors.New("Params must not be nil")
 	}
 	if !params.Format.IsValid() {
-		return errors.New("Invalid 'Format' parameter")
+		return nil, errors.New("Invalid 'Format' parameter")
+	}
+	if err := validateRules(params.Rules); err != nil {
+		return nil, err
+	}
+	rotate, trim := params.Rotate, params.Trim
+	for _, rule := range params.Rules {
+		rotate = rotate || (rule.Rotate != nil && *rule.Rotate)
+		trim = trim || (rule.Trim != nil && *rule.Trim)
+	}
+	if rotate && !params.Format.SupportsRotation {
+		return nil, fmt.Errorf("Format '%s' does not support rotation", params.Format.Name)
+	}
+	if trim && !params.Format.SupportsTrimming {
+		return nil, fmt.Errorf("Format '%s' does not support trimming", params.Format.Name)
 	}
 
 	ctx, cancelCtx := context.WithCancel(ctx)
@@ -113,91 +252,350 @@ func Run(ctx context.Context, params *Params) error {
 
 	// Validate the parameters
 	if err := params.validateRequiredParameters(); err != nil {
-		return err
+		return nil, err
 	}
 	params.applySensibleDefaults()
+	events := newEventLog(params.Events)
 
+	if params.Cache != nil {
+		return runCached(ctx, params, events)
+	}
+	atlases, err := build(ctx, params, events)
+	if err != nil {
+		return nil, err
+	}
+	return newResult(atlases), nil
+}
+
+// build reads the sprites from the input, lays them
+// out into atlases and writes the atlases to the output
+func build(ctx context.Context, params *Params, events *eventLog) ([]*atlas, error) {
 	// Read the images from the input directory
-	sprites, err := readAssetStream(ctx, params.Input, params.Padding)
+	sprites, err := readAssetStream(ctx, params.Input, params.spriteSettings(), params.Rules, events)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNames(sprites); err != nil {
+		return nil, err
+	}
+	if err := checkGroups(sprites, params.Name); err != nil {
+		return nil, err
+	}
+
+	events.expect(SpritePlaced, len(sprites))
+	atlases, err := layoutGroups(ctx, sprites, params, events)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if err := outputAtlases(ctx, atlases, params, events); err != nil {
+		return nil, err
+	}
+	return atlases, nil
+}
+
+// outputAtlases writes the image and descriptor of each of the atlases
+func outputAtlases(ctx context.Context, atlases []*atlas, params *Params, events *eventLog) error {
+	for _, kind := range []EventKind{AtlasStarted, AtlasImageEncoded, DescriptorWritten} {
+		events.expect(kind, len(atlases))
 	}
-	// TODO allow sorting algorithm to be specified
-	sort.Sort(packing.ByArea(sprites))
 
-	totalNumberOfSprites := len(sprites)
-	totalNumberOfAtlases := 0
-	completedSprites := make([]packing.Block, 0, totalNumberOfSprites)
-	incompleteSprites := make([]packing.Block, 0, totalNumberOfSprites)
 	wg := &sync.WaitGroup{}
 	errc := make(chan error)
+	for _, a := range atlases {
+		wg.Add(1)
+		go func(ctx context.Context, a *atlas, errc chan<- error, wg *sync.WaitGroup) {
+			events.emit(AtlasStarted, a.Name, 0)
+			select {
+			case errc <- a.Output(params.Output, params.Format.Template, events):
+			case <-ctx.Done():
+			}
+			wg.Done()
+		}(ctx, a, errc, wg)
+	}
+
+	go func() {
+		wg.Wait()
+		close(errc)
+	}()
+
+	for err := range errc {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkNames returns an error if any two sprites have the same name
+func checkNames(sprites []packing.Block) error {
+	sources := map[string]string{}
+	for _, block := range sprites {
+		spr := block.(*sprite)
+		name := spr.Name()
+		if other, ok := sources[name]; ok {
+			return fmt.Errorf("Assets '%s' and '%s' are both named '%s'", other, assetSource(spr.Asset), name)
+		}
+		sources[name] = assetSource(spr.Asset)
+	}
+	return nil
+}
+
+// checkGroups returns an error if any of the sprites is in a group with
+// the given name of the atlases of the sprites that are not in a group.
+// The name may be used by a group when every sprite is in a group.
+func checkGroups(sprites []packing.Block, name string) error {
+	var named *sprite
+	ungrouped := false
+	for _, block := range sprites {
+		spr := block.(*sprite)
+		if spr.group == "" {
+			ungrouped = true
+		} else if spr.group == name && named == nil {
+			named = spr
+		}
+	}
+	if ungrouped && named != nil {
+		return fmt.Errorf("Asset '%s' is in the group '%s' which has the same name as the atlases of ungrouped assets", assetSource(named.Asset), name)
+	}
+	return nil
+}
+
+// layout arranges the sprites into as many atlases as are required
+// using the given packing algorithm. Sprites are packed in the order given.
+func layout(ctx context.Context, sprites []packing.Block, params *Params, algorithm packing.Factory) ([]*atlas, error) {
+	maxWidth, maxHeight := maxAtlasSize(params)
+
+	var atlases []*atlas
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		// Return error if maxAtlases param exceeded
-		if params.MaxAtlases > 0 && totalNumberOfAtlases == params.MaxAtlases {
-			return fmt.Errorf("Maximum number of atlases (%d) exceeded", params.MaxAtlases)
+		if params.MaxAtlases > 0 && len(atlases) == params.MaxAtlases {
+			return nil, fmt.Errorf("Maximum number of atlases (%d) exceeded", params.MaxAtlases)
 		}
 
 		// Arrange the images into the atlas space
-		completedSprites = completedSprites[:0]
-		incompleteSprites = incompleteSprites[:0]
-		packer := packing.NewBinPacker(params.Width, params.Height)
-		for _, sprite := range sprites {
-			switch packer.Pack(sprite) {
-			case packing.ErrInputTooLarge:
-				return packing.ErrInputTooLarge
-			case packing.ErrOutOfRoom:
-				incompleteSprites = append(incompleteSprites, sprite)
-			default:
-				completedSprites = append(completedSprites, sprite)
-			}
+		completedSprites, incompleteSprites, err := pack(sprites, params, algorithm, maxWidth, maxHeight)
+		if err != nil {
+			return nil, err
+		}
+
+		// If we don't make any progress, then we've failed
+		if len(completedSprites) == 0 && len(incompleteSprites) > 0 {
+			return nil, packing.ErrOutOfRoom
 		}
 
-		totalNumberOfAtlases++
-		atlasName := fmt.Sprintf("%s-%d", params.Name, totalNumberOfAtlases)
-		atlas := &atlas{
+		atlasName := fmt.Sprintf("%s-%d", params.Name, len(atlases)+1)
+		a := &atlas{
 			Name:         atlasName,
 			Sprites:      completedSprites,
 			DescFilename: fmt.Sprintf("%s.%s", atlasName, params.Format.Ext),
 			// TODO add image type parameter
 			ImageFilename: fmt.Sprintf("%s.%s", atlasName, "png"),
-			Width:         params.Width,
-			Height:        params.Height,
 		}
-		wg.Add(1)
 
-		go func(ctx context.Context, errc chan<- error, wg *sync.WaitGroup) {
-			select {
-			case errc <- atlas.Output(params.Output, params.Format.Template):
-			case <-ctx.Done():
+		// Shrink the atlas to the smallest size that is still valid
+		if params.PowerOfTwo || params.Square {
+			a.Width, a.Height = smallestAtlasSize(ctx, completedSprites, params, algorithm, maxWidth, maxHeight)
+			if _, _, err := pack(completedSprites, params, algorithm, a.Width, a.Height); err != nil {
+				return nil, err
 			}
-			wg.Done()
-		}(ctx, errc, wg)
+		} else {
+			if params.Width == 0 || params.Height == 0 {
+				width, height := unboundedAtlasSize(ctx, completedSprites, params, algorithm, maxWidth, maxHeight)
+				if _, _, err := pack(completedSprites, params, algorithm, width, height); err != nil {
+					return nil, err
+				}
+			}
+			a.Width, a.Height = croppedAtlasSize(a, params, maxWidth, maxHeight)
+		}
+		// The size searches give up when cancelled
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		atlases = append(atlases, a)
 
-		totalNumberOfIncompletedSprites := len(incompleteSprites)
 		// If there are no more sprites that are incomplete, we are done!
-		if totalNumberOfIncompletedSprites == 0 {
-			break
-		}
-		// If we don't make any progress, then we've failed
-		if totalNumberOfIncompletedSprites == totalNumberOfSprites {
-			return packing.ErrOutOfRoom
+		if len(incompleteSprites) == 0 {
+			return atlases, nil
 		}
 		// Otherwise continue
 		sprites = incompleteSprites
 	}
+}
+
+// pack places as many of the sprites as possible into an atlas of the
+// given size, returning the sprites that were placed and those that were not.
+func pack(sprites []packing.Block, params *Params, algorithm packing.Factory, width, height int) ([]packing.Block, []packing.Block, error) {
+	completedSprites := make([]packing.Block, 0, len(sprites))
+	incompleteSprites := make([]packing.Block, 0, len(sprites))
+
+	binWidth, binHeight := binSize(sprites, params, width, height)
+	packer := algorithm(binWidth, binHeight)
+	for _, sprite := range sprites {
+		switch packer.Pack(sprite) {
+		case packing.ErrInputTooLarge:
+			return nil, nil, packing.ErrInputTooLarge
+		case packing.ErrOutOfRoom:
+			incompleteSprites = append(incompleteSprites, sprite)
+		default:
+			completedSprites = append(completedSprites, sprite)
+		}
+	}
+	return completedSprites, incompleteSprites, nil
+}
 
+// binSize returns the size of the space that the sprites
+// are packed into for an atlas of the given size
+func binSize(sprites []packing.Block, params *Params, width, height int) (int, int) {
+	// Every block includes the shape padding to its right and below it,
+	// the last row and column of blocks can let this overhang the border.
+	// Rules can give sprites differing padding so only the smallest
+	// padding is certain to fit
+	overhang := params.shapePadding()
+	for _, block := range sprites {
+		if padding := block.(*sprite).shapePadding; padding < overhang {
+			overhang = padding
+		}
+	}
+	return width - 2*params.borderPadding() + overhang, height - 2*params.borderPadding() + overhang
+}
+
+// layoutCandidate is a single combination of packing
+// algorithm and sort order tried by layoutBest
+type layoutCandidate struct {
+	algorithm string
+	sort      string
+
+	atlases []*atlas
+	err     error
+}
+
+// layoutBest arranges the sprites with every registered packing algorithm
+// and sort order and returns the layout that uses the fewest atlases,
+// then the least total area. Candidates are laid out concurrently.
+func layoutBest(ctx context.Context, sprites []packing.Block, params *Params) ([]*atlas, error) {
+	var candidates []*layoutCandidate
+	for _, algorithm := range packing.Algorithms() {
+		for _, sortOrder := range packing.SortOrders() {
+			candidates = append(candidates, &layoutCandidate{algorithm: algorithm, sort: sortOrder})
+		}
+	}
+
+	in := make(chan *layoutCandidate)
 	go func() {
-		wg.Wait()
-		close(errc)
+		defer close(in)
+		for _, c := range candidates {
+			select {
+			case in <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
 	}()
 
-	for err := range errc {
-		if err != nil {
-			return err
+	numWorkers := runtime.NumCPU()
+	var wg sync.WaitGroup
+	wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for c := range in {
+				// Each candidate places its own copy of the sprites
+				candidateSprites := cloneSprites(sprites)
+				packing.SortOrderNamed(c.sort)(candidateSprites)
+				c.atlases, c.err = layout(ctx, candidateSprites, params, packing.AlgorithmNamed(c.algorithm))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var best *layoutCandidate
+	for _, c := range candidates {
+		if c.err != nil {
+			continue
+		}
+		if best == nil || len(c.atlases) < len(best.atlases) ||
+			(len(c.atlases) == len(best.atlases) && totalArea(c.atlases) < totalArea(best.atlases)) {
+			best = c
 		}
 	}
+	if best == nil {
+		return nil, candidates[0].err
+	}
+	return best.atlases, nil
+}
 
-	return nil
+// cloneSprites returns a copy of every sprite so that
+// they can be placed without affecting the originals
+func cloneSprites(sprites []packing.Block) []packing.Block {
+	clones := make([]packing.Block, len(sprites))
+	for i, block := range sprites {
+		clone := *block.(*sprite)
+		clones[i] = &clone
+	}
+	return clones
+}
+
+// totalArea returns the sum of the area of each of the atlases
+func totalArea(atlases []*atlas) int {
+	area := 0
+	for _, a := range atlases {
+		area += a.Width * a.Height
+	}
+	return area
+}
+
+// borderPadding returns the BorderPadding, or Padding if it is not given
+func (p *Params) borderPadding() int {
+	if p.BorderPadding != nil {
+		return *p.BorderPadding
+	}
+	return p.Padding
+}
+
+// shapePadding returns the ShapePadding, or Padding if it is not given
+func (p *Params) shapePadding() int {
+	if p.ShapePadding != nil {
+		return *p.ShapePadding
+	}
+	return p.Padding
+}
+
+// spriteSettings returns the settings used for every sprite
+// before any of the rules are applied
+func (p *Params) spriteSettings() spriteSettings {
+	return spriteSettings{
+		borderPadding: p.borderPadding(),
+		shapePadding:  p.shapePadding(),
+		separator:     p.NameSeparator,
+		extrude:       p.Extrude,
+		rotate:        p.Rotate,
+		trim:          p.Trim,
+		deduplicate:   p.Deduplicate,
+		groupByDir:    p.GroupByDirectory,
+	}
+}
+
+// spriteSettings configure how each sprite is packed
+type spriteSettings struct {
+	separator     string
+	borderPadding int
+	shapePadding  int
+	extrude       int
+	rotate        bool
+	trim          bool
+	deduplicate   bool
+	groupByDir    bool
+	group         string
 }
 
 type assetDecodeResult struct {
@@ -205,7 +603,7 @@ type assetDecodeResult struct {
 	Err    error
 }
 
-func readAssetStream(ctx context.Context, assetStream AssetStreamer, padding int) ([]packing.Block, error) {
+func readAssetStream(ctx context.Context, assetStream AssetStreamer, settings spriteSettings, rules []Rule, events *eventLog) ([]packing.Block, error) {
 	ctx, cancelCtx := context.WithCancel(ctx)
 	defer cancelCtx()
 	// Stream the input
@@ -217,7 +615,7 @@ func readAssetStream(ctx context.Context, assetStream AssetStreamer, padding int
 	wg.Add(numDecoders)
 	for i := 0; i < numDecoders; i++ {
 		go func() {
-			decode(ctx, padding, assets, out)
+			decode(ctx, settings, rules, events, assets, out)
 			wg.Done()
 		}()
 	}
@@ -239,13 +637,19 @@ func readAssetStream(ctx context.Context, assetStream AssetStreamer, padding int
 		return nil, err
 	}
 
+	// The decoders finish in any order, sort the sprites
+	// so that the output is the same every time
+	sort.Slice(sprites, func(i, j int) bool {
+		return sprites[i].(*sprite).path < sprites[j].(*sprite).path
+	})
 	return sprites, nil
 }
 
 // Decodes assets from the in channel and publishes the results to
 // the out channel. Will continue even after errors have been discovered
-// cancel the context to interrupt early.
-func decode(ctx context.Context, padding int, in <-chan Asset, out chan<- *assetDecodeResult) {
+// cancel the context to interrupt early. The settings of each sprite
+// are the defaults given with any matching rules applied.
+func decode(ctx context.Context, defaults spriteSettings, rules []Rule, events *eventLog, in <-chan Asset, out chan<- *assetDecodeResult) {
 	publishResult := func(spr *sprite, err error) {
 		select {
 		case out <- &assetDecodeResult{spr, err}:
@@ -255,6 +659,9 @@ func decode(ctx context.Context, padding int, in <-chan Asset, out chan<- *asset
 
 	for asset := range in {
 		assetPath := asset.Asset()
+		events.emit(AssetDiscovered, assetPath, 0)
+		start := time.Now()
+		settings := settingsFor(assetPath, defaults, rules)
 		assetReader, err := asset.Reader()
 		if err != nil {
 			publishResult(nil, fmt.Errorf("Failed to read asset '%s': %s", assetPath, err))
@@ -262,20 +669,72 @@ func decode(ctx context.Context, padding int, in <-chan Asset, out chan<- *asset
 		}
 		defer assetReader.Close()
 
-		cfg, _, err := image.DecodeConfig(assetReader)
+		var spr *sprite
+		if settings.trim || settings.deduplicate {
+			spr, err = decodeImage(assetReader, settings)
+		} else {
+			spr, err = decodeConfig(assetReader)
+		}
 		if err != nil {
 			publishResult(nil, fmt.Errorf("Failed to read asset metadata '%s': %s", assetPath, err))
 			continue
 		}
 
-		spr := &sprite{
-			Asset:   asset,
-			path:    assetPath,
-			w:       cfg.Width,
-			h:       cfg.Height,
-			padding: padding,
+		spr.Asset = asset
+		spr.path = assetPath
+		spr.separator = settings.separator
+		spr.group = settings.group
+		if spr.group == "" && settings.groupByDir {
+			spr.group = directoryGroup(assetPath)
 		}
+		spr.borderPadding = settings.borderPadding
+		spr.shapePadding = settings.shapePadding
+		spr.extrude = settings.extrude
+		spr.rotatable = settings.rotate
 
+		events.emit(AssetDecoded, assetPath, time.Since(start))
 		publishResult(spr, nil)
 	}
 }
+
+// decodeConfig creates a sprite the size of the image
+// without decoding the entire image
+func decodeConfig(r io.Reader) (*sprite, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	return &sprite{
+		w:       cfg.Width,
+		h:       cfg.Height,
+		sourceW: cfg.Width,
+		sourceH: cfg.Height,
+	}, nil
+}
+
+// decodeImage decodes the entire image and creates a sprite from it.
+// If trimming the sprite will be the size of the non-transparent pixels
+// in the image. If deduplicating the pixels of the sprite are hashed.
+func decodeImage(r io.Reader, settings spriteSettings) (*sprite, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	trimmed := b
+	if settings.trim {
+		trimmed = opaqueBounds(img)
+	}
+	spr := &sprite{
+		w:       trimmed.Dx(),
+		h:       trimmed.Dy(),
+		offsetX: trimmed.Min.X - b.Min.X,
+		offsetY: trimmed.Min.Y - b.Min.Y,
+		sourceW: b.Dx(),
+		sourceH: b.Dy(),
+	}
+	if settings.deduplicate {
+		spr.hash = hashPixels(img, trimmed)
+	}
+	return spr, nil
+}
@@ -2,12 +2,21 @@ package packer_test
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
 	"testing"
 
 	"strings"
 
 	"github.com/RaniSputnik/lovepac/packer"
+	"github.com/RaniSputnik/lovepac/packing"
 	"github.com/RaniSputnik/lovepac/target"
 )
 
@@ -197,6 +206,588 @@ func TestRunWithTooManyFilesAndMaxAtlasesResultsInError(t *testing.T) {
 	}
 }
 
+func TestRunUsesTheGivenAlgorithm(t *testing.T) {
+	files := []string{
+		"button_active.png",
+		"button_hover.png",
+		"button.png",
+	}
+	buttonWidth, buttonHeight := 124, 50
+
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Love,
+		Input:  packer.NewFilenameStream("./fixtures", files...),
+		Output: outputRecorder,
+		Algorithm: func(width, height int) packing.Packer {
+			return packing.NewShelfPacker(width, height, packing.ShelfNextFit)
+		},
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Errorf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	// The buttons are all the same size, so the shelf
+	// packer will arrange them in a single row
+	gotStr := got["atlas-1.lua"].String()
+	for i := range files {
+		expectedString := fmt.Sprintf("love.graphics.newQuad(%d,%d,%d,%d,%d,%d)",
+			i*buttonWidth, 0, buttonWidth, buttonHeight, len(files)*buttonWidth, buttonHeight)
+		if !strings.Contains(gotStr, expectedString) {
+			t.Errorf("Expected descriptor to contain the following sub-string\n\n%s\n%s\n\n%s",
+				expectedString, createUnderlineString(expectedString), gotStr)
+		}
+	}
+}
+
+func TestRunPacksSpritesInTheGivenSortOrder(t *testing.T) {
+	files := []string{
+		"button_active.png",
+		"button_hover.png",
+		"button.png",
+	}
+	buttonWidth, buttonHeight := 124, 50
+
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Love,
+		Input:  packer.NewFilenameStream("./fixtures", files...),
+		Output: outputRecorder,
+		Algorithm: func(width, height int) packing.Packer {
+			return packing.NewShelfPacker(width, height, packing.ShelfNextFit)
+		},
+		Sort: packing.SortOrderNamed("name-reverse"),
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Errorf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	gotStr := got["atlas-1.lua"].String()
+	for i, name := range []string{"button_hover", "button_active", "button"} {
+		expectedString := fmt.Sprintf("quads['%s'] = love.graphics.newQuad(%d,%d,%d,%d,%d,%d)",
+			name, i*buttonWidth, 0, buttonWidth, buttonHeight, 3*buttonWidth, buttonHeight)
+		if !strings.Contains(gotStr, expectedString) {
+			t.Errorf("Expected descriptor to contain the following sub-string\n\n%s\n%s\n\n%s",
+				expectedString, createUnderlineString(expectedString), gotStr)
+		}
+	}
+}
+
+func TestRunTryAllKeepsTheLayoutWithFewestAtlases(t *testing.T) {
+	files := []string{
+		"button_active.png",
+		"button_hover.png",
+		"button.png",
+		"character_evil.png",
+		"character_hero.png",
+	}
+	expected := map[string]string{
+		fmt.Sprintf("%s-1.png", packer.DefaultAtlasName): "",
+		fmt.Sprintf("%s-1.lua", packer.DefaultAtlasName): "",
+		fmt.Sprintf("%s-2.png", packer.DefaultAtlasName): "",
+		fmt.Sprintf("%s-2.lua", packer.DefaultAtlasName): "",
+	}
+
+	// Register an algorithm that will only ever fit a single sprite
+	// into each atlas, it should never be chosen
+	packing.Register("one-per-atlas", func(width, height int) packing.Packer {
+		return &onePerAtlasPacker{}
+	})
+	defer packing.Unregister("one-per-atlas")
+
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Love,
+		Input:  packer.NewFilenameStream("./fixtures", files...),
+		Output: outputRecorder,
+		Width:  400,
+		Height: 400,
+		TryAll: true,
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Errorf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	for gotFile := range got {
+		if _, ok := expected[gotFile]; !ok {
+			t.Errorf("Got unexpected file '%s'", gotFile)
+		}
+	}
+
+	for expect := range expected {
+		if _, ok := got[expect]; !ok {
+			t.Errorf("Expected file '%s' to be outputted", expect)
+		}
+	}
+}
+
+func TestRunTryAllKeepsTheSmallestLayoutWhenTheAtlasesTie(t *testing.T) {
+	files := []string{
+		"button_active.png",
+		"button_hover.png",
+		"button.png",
+		"character_evil.png",
+		"character_hero.png",
+	}
+	diagonal := func(width, height int) packing.Packer {
+		return &diagonalPacker{width: width, height: height}
+	}
+	area := func(algorithm packing.Factory, tryAll bool) int {
+		result, err := packer.RunWithResult(context.Background(), &packer.Params{
+			Format:    target.Love,
+			Input:     packer.NewFilenameStream("./fixtures", files...),
+			Output:    NewOutputRecorder(),
+			Width:     2048,
+			Height:    2048,
+			Algorithm: algorithm,
+			TryAll:    tryAll,
+		})
+		if err != nil {
+			t.Fatalf("Expected run to succeed without error but got '%s'", err)
+		}
+		if len(result.Atlases) != 1 {
+			t.Fatalf("Expected every sprite to fit in 1 atlas but got %d", len(result.Atlases))
+		}
+		return result.Atlases[0].Width * result.Atlases[0].Height
+	}
+
+	// Every candidate packs the sprites into a single atlas,
+	// the diagonal layout is the largest and should never be chosen
+	diagonalArea := area(diagonal, false)
+	binPackArea := area(nil, false)
+	packing.Register("diagonal", diagonal)
+	defer packing.Unregister("diagonal")
+
+	got := area(nil, true)
+	if got >= diagonalArea {
+		t.Errorf("Expected an atlas smaller than the diagonal layout of area %d but got %d", diagonalArea, got)
+	}
+	if got > binPackArea {
+		t.Errorf("Expected an atlas no larger than the bin packed layout of area %d but got %d", binPackArea, got)
+	}
+}
+
+func TestRunRotatesSpritesThatOnlyFitRotated(t *testing.T) {
+	character := "character_evil.png"
+	characterWidth, characterHeight := 286, 355
+
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Starling,
+		Input:  packer.NewFilenameStream("./fixtures", character),
+		Output: outputRecorder,
+		// The character only fits if it is rotated
+		Width:  characterHeight,
+		Height: characterWidth,
+		Rotate: true,
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	expectedString := fmt.Sprintf(`<SubTexture name="character_evil" x="0" y="0" width="%d" height="%d" rotated="true"/>`,
+		characterHeight, characterWidth)
+	gotStr := got["atlas-1.xml"].String()
+	if !strings.Contains(gotStr, expectedString) {
+		t.Errorf("Expected descriptor to contain the following sub-string\n\n%s\n%s\n\n%s",
+			expectedString, createUnderlineString(expectedString), gotStr)
+	}
+
+	// The image should have been rotated clockwise
+	// into the atlas, compare the pixels of both
+	f, err := os.Open(filepath.Join("./fixtures", character))
+	if err != nil {
+		t.Fatalf("Failed to open fixture '%s': %s", character, err)
+	}
+	defer f.Close()
+	src, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("Failed to decode fixture '%s': %s", character, err)
+	}
+	atlasImg, err := png.Decode(got["atlas-1.png"])
+	if err != nil {
+		t.Fatalf("Failed to decode atlas image: %s", err)
+	}
+	for y := 0; y < characterHeight; y += 7 {
+		for x := 0; x < characterWidth; x += 7 {
+			expected := color.NRGBAModel.Convert(src.At(x, y))
+			gotColor := color.NRGBAModel.Convert(atlasImg.At(characterHeight-1-y, x))
+			if expected != gotColor {
+				t.Fatalf("Expected pixel {%d,%d} of the sprite to be %v in the atlas but got %v", x, y, expected, gotColor)
+			}
+		}
+	}
+}
+
+func TestRunWithRotationFailsIfTheFormatDoesNotSupportIt(t *testing.T) {
+	params := &packer.Params{
+		Format: target.Love,
+		Input:  packer.NewFilenameStream("./fixtures", "button.png"),
+		Output: NewOutputRecorder(),
+		Rotate: true,
+	}
+
+	err := packer.Run(context.Background(), params)
+	if err == nil {
+		t.Errorf("Expected run to fail but error was nil")
+	}
+}
+
+func TestRunTrimsTransparentEdges(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Starling,
+		Input: newBytesAssetStream(
+			newImageAsset(t, "padded.png", 100, 80, image.Rect(10, 20, 40, 60), red),
+		),
+		Output: outputRecorder,
+		Trim:   true,
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	expectedString := `<SubTexture name="padded" x="0" y="0" width="30" height="40" frameX="-10" frameY="-20" frameWidth="100" frameHeight="80"/>`
+	gotStr := got["atlas-1.xml"].String()
+	if !strings.Contains(gotStr, expectedString) {
+		t.Errorf("Expected descriptor to contain the following sub-string\n\n%s\n%s\n\n%s",
+			expectedString, createUnderlineString(expectedString), gotStr)
+	}
+
+	atlasImg, err := png.Decode(got["atlas-1.png"])
+	if err != nil {
+		t.Fatalf("Failed to decode atlas image: %s", err)
+	}
+	for _, p := range []image.Point{{0, 0}, {29, 0}, {0, 39}, {29, 39}} {
+		if gotColor := color.NRGBAModel.Convert(atlasImg.At(p.X, p.Y)); gotColor != red {
+			t.Errorf("Expected pixel {%d,%d} of the atlas to be %v but got %v", p.X, p.Y, red, gotColor)
+		}
+	}
+}
+
+func TestRunWithTrimmingFailsIfTheFormatDoesNotSupportIt(t *testing.T) {
+	params := &packer.Params{
+		Format: target.Love,
+		Input:  packer.NewFilenameStream("./fixtures", "button.png"),
+		Output: NewOutputRecorder(),
+		Trim:   true,
+	}
+
+	err := packer.Run(context.Background(), params)
+	if err == nil {
+		t.Errorf("Expected run to fail but error was nil")
+	}
+}
+
+func TestRunPacksIdenticalSpritesOnce(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	blue := color.NRGBA{0, 0, 255, 255}
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Love,
+		Input: newBytesAssetStream(
+			newImageAsset(t, "red.png", 50, 50, image.Rect(0, 0, 50, 50), red),
+			newImageAsset(t, "red_copy.png", 50, 50, image.Rect(0, 0, 50, 50), red),
+			newImageAsset(t, "blue.png", 50, 50, image.Rect(0, 0, 50, 50), blue),
+		),
+		Output: outputRecorder,
+		// Only enough room for two of the sprites
+		Width:       100,
+		Height:      50,
+		MaxAtlases:  1,
+		Deduplicate: true,
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	gotStr := got["atlas-1.lua"].String()
+	var redQuad string
+	for _, line := range strings.Split(gotStr, "\n") {
+		if strings.HasPrefix(line, "quads['red']") {
+			redQuad = strings.TrimPrefix(line, "quads['red']")
+		}
+	}
+	expectedString := "quads['red_copy']" + redQuad
+	if redQuad == "" || !strings.Contains(gotStr, expectedString) {
+		t.Errorf("Expected descriptor to contain the following sub-string\n\n%s\n%s\n\n%s",
+			expectedString, createUnderlineString(expectedString), gotStr)
+	}
+}
+
+func TestRunPacksSpritesThatAreIdenticalOnceTrimmedOnce(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Starling,
+		Input: newBytesAssetStream(
+			newImageAsset(t, "a.png", 100, 80, image.Rect(10, 20, 40, 60), red),
+			newImageAsset(t, "b.png", 60, 60, image.Rect(0, 0, 30, 40), red),
+		),
+		Output: outputRecorder,
+		// Only enough room for one of the trimmed sprites
+		Width:       30,
+		Height:      40,
+		MaxAtlases:  1,
+		Trim:        true,
+		Deduplicate: true,
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	gotStr := got["atlas-1.xml"].String()
+	for _, expectedString := range []string{
+		`<SubTexture name="a" x="0" y="0" width="30" height="40" frameX="-10" frameY="-20" frameWidth="100" frameHeight="80"/>`,
+		`<SubTexture name="b" x="0" y="0" width="30" height="40" frameX="0" frameY="0" frameWidth="60" frameHeight="60"/>`,
+	} {
+		if !strings.Contains(gotStr, expectedString) {
+			t.Errorf("Expected descriptor to contain the following sub-string\n\n%s\n%s\n\n%s",
+				expectedString, createUnderlineString(expectedString), gotStr)
+		}
+	}
+}
+
+func TestRunExtrudesSpriteEdges(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	transparent := color.NRGBA{}
+	extrude := 2
+
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Love,
+		Input: newBytesAssetStream(
+			// The top half of the image is red, the bottom half transparent
+			newImageAsset(t, "half.png", 10, 10, image.Rect(0, 0, 10, 5), red),
+		),
+		Output:  outputRecorder,
+		Width:   10 + 2*extrude,
+		Height:  10 + 2*extrude,
+		Extrude: extrude,
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	expectedString := fmt.Sprintf("quads['half'] = love.graphics.newQuad(%d,%d,%d,%d,%d,%d)",
+		extrude, extrude, 10, 10, params.Width, params.Height)
+	gotStr := got["atlas-1.lua"].String()
+	if !strings.Contains(gotStr, expectedString) {
+		t.Errorf("Expected descriptor to contain the following sub-string\n\n%s\n%s\n\n%s",
+			expectedString, createUnderlineString(expectedString), gotStr)
+	}
+
+	atlasImg, err := png.Decode(got["atlas-1.png"])
+	if err != nil {
+		t.Fatalf("Failed to decode atlas image: %s", err)
+	}
+	expectedPixels := map[image.Point]color.NRGBA{
+		{0, 0}:   red,         // top left corner
+		{13, 0}:  red,         // top right corner
+		{7, 1}:   red,         // above the top edge
+		{0, 4}:   red,         // left of a red row
+		{13, 6}:  red,         // right of a red row
+		{0, 9}:   transparent, // left of a transparent row
+		{13, 12}: transparent, // bottom right corner
+	}
+	for p, expected := range expectedPixels {
+		if gotColor := color.NRGBAModel.Convert(atlasImg.At(p.X, p.Y)); gotColor != expected {
+			t.Errorf("Expected pixel {%d,%d} of the atlas to be %v but got %v", p.X, p.Y, expected, gotColor)
+		}
+	}
+}
+
+func TestRunAppliesRulesToMatchingAssets(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	extrude := 2
+
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Love,
+		Input: newBytesAssetStream(
+			newImageAsset(t, "icon.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+			newImageAsset(t, "tile_grass.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+		),
+		Output: outputRecorder,
+		Algorithm: func(width, height int) packing.Packer {
+			return packing.NewShelfPacker(width, height, packing.ShelfNextFit)
+		},
+		Sort:  packing.SortOrderNamed("name"),
+		Rules: []packer.Rule{{Pattern: "tile_*", Extrude: &extrude}},
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	gotStr := got["atlas-1.lua"].String()
+	for _, expectedString := range []string{
+		// The icon is not extruded
+		fmt.Sprintf("quads['icon'] = love.graphics.newQuad(%d,%d,%d,%d,%d,%d)",
+			0, 0, 10, 10, 10+10+2*extrude, 10+2*extrude),
+		// The tile is extruded on all sides
+		fmt.Sprintf("quads['tile_grass'] = love.graphics.newQuad(%d,%d,%d,%d,%d,%d)",
+			10+extrude, extrude, 10, 10, 10+10+2*extrude, 10+2*extrude),
+	} {
+		if !strings.Contains(gotStr, expectedString) {
+			t.Errorf("Expected descriptor to contain the following sub-string\n\n%s\n%s\n\n%s",
+				expectedString, createUnderlineString(expectedString), gotStr)
+		}
+	}
+}
+
+func TestRunMatchesRulesWithoutADirectoryAgainstTheFileName(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	extrude := 2
+
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Love,
+		Input: newBytesAssetStream(
+			newImageAsset(t, "tiles/tile_grass.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+		),
+		Output: outputRecorder,
+		Rules:  []packer.Rule{{Pattern: "tile_*.png", Extrude: &extrude}},
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	gotStr := got["atlas-1.lua"].String()
+	expectedString := fmt.Sprintf("quads['tiles/tile_grass'] = love.graphics.newQuad(%d,%d,%d,%d,%d,%d)",
+		extrude, extrude, 10, 10, 10+2*extrude, 10+2*extrude)
+	if !strings.Contains(gotStr, expectedString) {
+		t.Errorf("Expected descriptor to contain the following sub-string\n\n%s\n%s\n\n%s",
+			expectedString, createUnderlineString(expectedString), gotStr)
+	}
+}
+
+func TestRunAppliesTheBorderPaddingOfRulesToTheirGroup(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	borderPadding := 4
+
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Love,
+		Input: newBytesAssetStream(
+			newImageAsset(t, "icon.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+			newImageAsset(t, "tiles/grass.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+		),
+		Output: outputRecorder,
+		Rules:  []packer.Rule{{Pattern: "tiles/*", Group: "tiles", BorderPadding: &borderPadding}},
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	for filename, expectedString := range map[string]string{
+		"atlas-1.lua": "quads['icon'] = love.graphics.newQuad(0,0,10,10,10,10)",
+		"tiles-1.lua": fmt.Sprintf("quads['tiles/grass'] = love.graphics.newQuad(%d,%d,%d,%d,%d,%d)",
+			borderPadding, borderPadding, 10, 10, 10+2*borderPadding, 10+2*borderPadding),
+	} {
+		gotStr := got[filename].String()
+		if !strings.Contains(gotStr, expectedString) {
+			t.Errorf("Expected %s to contain the following sub-string\n\n%s\n%s\n\n%s",
+				filename, expectedString, createUnderlineString(expectedString), gotStr)
+		}
+	}
+}
+
+func TestRunDoesNotDeduplicateSpritesWithDifferentRules(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	extrude := 2
+
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Love,
+		Input: newBytesAssetStream(
+			newImageAsset(t, "icon.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+			newImageAsset(t, "tile_grass.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+		),
+		Output: outputRecorder,
+		Algorithm: func(width, height int) packing.Packer {
+			return packing.NewShelfPacker(width, height, packing.ShelfNextFit)
+		},
+		Sort:        packing.SortOrderNamed("name"),
+		Rules:       []packer.Rule{{Pattern: "tile_*", Extrude: &extrude}},
+		Deduplicate: true,
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	// The extruded tile can't share the position of the icon
+	gotStr := got["atlas-1.lua"].String()
+	expectedString := fmt.Sprintf("quads['tile_grass'] = love.graphics.newQuad(%d,%d,%d,%d,%d,%d)",
+		10+extrude, extrude, 10, 10, 10+10+2*extrude, 10+2*extrude)
+	if !strings.Contains(gotStr, expectedString) {
+		t.Errorf("Expected descriptor to contain the following sub-string\n\n%s\n%s\n\n%s",
+			expectedString, createUnderlineString(expectedString), gotStr)
+	}
+}
+
+func TestRunWithRuleThatTrimsFailsIfTheFormatDoesNotSupportIt(t *testing.T) {
+	trim := true
+	params := &packer.Params{
+		Format: target.Love,
+		Input:  packer.NewFilenameStream("./fixtures", "button.png"),
+		Output: NewOutputRecorder(),
+		Rules:  []packer.Rule{{Pattern: "*", Trim: &trim}},
+	}
+
+	if err := packer.Run(context.Background(), params); err == nil {
+		t.Errorf("Expected run to fail as the format does not support trimming but got nil error")
+	}
+}
+
 func TestPaddingIsAppliedCorrectly(t *testing.T) {
 	button := "button.png"
 	buttonWidth, buttonHeight := 124, 50
@@ -219,7 +810,7 @@ func TestPaddingIsAppliedCorrectly(t *testing.T) {
 	}
 
 	expectedString := fmt.Sprintf("quads['button'] = love.graphics.newQuad(%d,%d,%d,%d,%d,%d)",
-		padding, padding, buttonWidth, buttonHeight, packer.DefaultAtlasWidth, packer.DefaultAtlasHeight)
+		padding, padding, buttonWidth, buttonHeight, buttonWidth+2*padding, buttonHeight+2*padding)
 	seperator := createUnderlineString(expectedString)
 	gotStr := got["atlas-1.lua"].String()
 	if !strings.Contains(gotStr, expectedString) {
@@ -229,6 +820,45 @@ func TestPaddingIsAppliedCorrectly(t *testing.T) {
 	// TODO do we want to ensure the image was placed correctly too?
 }
 
+func TestRunWithZeroBorderPaddingKeepsTheShapePadding(t *testing.T) {
+	buttonWidth, buttonHeight := 124, 50
+	padding, borderPadding := 2, 0
+
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Input:         packer.NewFilenameStream("./fixtures", "button.png", "button_active.png"),
+		Output:        outputRecorder,
+		Format:        target.Love,
+		Padding:       padding,
+		BorderPadding: &borderPadding,
+		// Only room for the buttons side by side without a border
+		Width:  400,
+		Height: buttonHeight,
+		Algorithm: func(width, height int) packing.Packer {
+			return packing.NewShelfPacker(width, height, packing.ShelfNextFit)
+		},
+		Sort: packing.SortOrderNamed("name"),
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	gotStr := got["atlas-1.lua"].String()
+	for _, expectedString := range []string{
+		fmt.Sprintf("love.graphics.newQuad(%d,%d,%d,%d,", 0, 0, buttonWidth, buttonHeight),
+		fmt.Sprintf("love.graphics.newQuad(%d,%d,%d,%d,", buttonWidth+padding, 0, buttonWidth, buttonHeight),
+	} {
+		if !strings.Contains(gotStr, expectedString) {
+			t.Errorf("Expected descriptor to contain the following sub-string\n\n%s\n%s\n\n%s",
+				expectedString, createUnderlineString(expectedString), gotStr)
+		}
+	}
+}
+
 func TestAssetsDoNotFitIfPaddingCannotBeApplied(t *testing.T) {
 	button := "button.png"
 	buttonWidth, buttonHeight := 124, 50
@@ -249,6 +879,557 @@ func TestAssetsDoNotFitIfPaddingCannotBeApplied(t *testing.T) {
 	}
 }
 
+var loveQuadPattern = regexp.MustCompile(`newQuad\((\d+),(\d+),(\d+),(\d+),`)
+
+func TestRunKeepsBorderAndShapePaddingForEveryAlgorithm(t *testing.T) {
+	borderPadding, shapePadding := 3, 5
+	width, height := 520, 420
+
+	for _, name := range packing.Algorithms() {
+		outputRecorder := NewOutputRecorder()
+		params := &packer.Params{
+			Format:        target.Love,
+			Input:         packer.NewFileStream("./fixtures"),
+			Output:        outputRecorder,
+			Width:         width,
+			Height:        height,
+			BorderPadding: &borderPadding,
+			ShapePadding:  &shapePadding,
+			Algorithm:     packing.AlgorithmNamed(name),
+		}
+
+		if err := packer.Run(context.Background(), params); err != nil {
+			t.Errorf("Expected %s run to succeed without error but got '%s'", name, err)
+			continue
+		}
+
+		for filename, got := range outputRecorder.Got() {
+			if filepath.Ext(filename) != ".lua" {
+				continue
+			}
+			var rects []image.Rectangle
+			for _, m := range loveQuadPattern.FindAllStringSubmatch(got.String(), -1) {
+				var x, y, w, h int
+				fmt.Sscan(strings.Join(m[1:], " "), &x, &y, &w, &h)
+				rects = append(rects, image.Rect(x, y, x+w, y+h))
+			}
+
+			usable := image.Rect(borderPadding, borderPadding, width-borderPadding, height-borderPadding)
+			for i, r := range rects {
+				if !r.In(usable) {
+					t.Errorf("Expected %s sprite %v in %s to be within the border %v", name, r, filename, usable)
+				}
+				for _, other := range rects[i+1:] {
+					// Grow one of the rectangles by the shape padding
+					// (less one pixel) they should still not overlap
+					grown := image.Rect(r.Min.X-shapePadding+1, r.Min.Y-shapePadding+1, r.Max.X+shapePadding-1, r.Max.Y+shapePadding-1)
+					if grown.Overlaps(other) {
+						t.Errorf("Expected %s sprites %v and %v in %s to be at least %d pixels apart", name, r, other, filename, shapePadding)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRunShrinksAtlasesToTheSmallestValidSize(t *testing.T) {
+	tests := []struct {
+		Name                          string
+		PowerOfTwo, Square            bool
+		ExpectedWidth, ExpectedHeight int
+	}{
+		{Name: "PowerOfTwo", PowerOfTwo: true, ExpectedWidth: 256, ExpectedHeight: 512},
+		{Name: "Square", Square: true, ExpectedWidth: 346, ExpectedHeight: 346},
+		{Name: "PowerOfTwoSquare", PowerOfTwo: true, Square: true, ExpectedWidth: 512, ExpectedHeight: 512},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			outputRecorder := NewOutputRecorder()
+			params := &packer.Params{
+				Format:     target.Love,
+				Input:      packer.NewFilenameStream("./fixtures", "button.png", "button_hover.png", "character_hero.png"),
+				Output:     outputRecorder,
+				Width:      1000,
+				Height:     1000,
+				PowerOfTwo: test.PowerOfTwo,
+				Square:     test.Square,
+			}
+
+			err := packer.Run(context.Background(), params)
+			got := outputRecorder.Got()
+
+			if err != nil {
+				t.Fatalf("Expected run to succeed without error but got '%s'", err)
+			}
+			if _, ok := got["atlas-2.png"]; ok {
+				t.Errorf("Expected all of the sprites to fit in one atlas")
+			}
+
+			atlasImg, err := png.Decode(got["atlas-1.png"])
+			if err != nil {
+				t.Fatalf("Failed to decode atlas image: %s", err)
+			}
+			if size := atlasImg.Bounds().Size(); size.X != test.ExpectedWidth || size.Y != test.ExpectedHeight {
+				t.Errorf("Expected atlas to be %dx%d but got %dx%d",
+					test.ExpectedWidth, test.ExpectedHeight, size.X, size.Y)
+			}
+
+			expectedString := fmt.Sprintf(",%d,%d)", test.ExpectedWidth, test.ExpectedHeight)
+			if gotStr := got["atlas-1.lua"].String(); !strings.Contains(gotStr, expectedString) {
+				t.Errorf("Expected descriptor quads to end with '%s' but got\n\n%s", expectedString, gotStr)
+			}
+		})
+	}
+}
+
+func TestRunCropsAtlasesToTheAreaUsed(t *testing.T) {
+	buttonWidth, buttonHeight := 124, 50
+
+	tests := []struct {
+		Name                          string
+		SizeMultiple                  int
+		ExpectedWidth, ExpectedHeight int
+	}{
+		{Name: "Bounds", ExpectedWidth: buttonWidth, ExpectedHeight: buttonHeight},
+		{Name: "Multiple", SizeMultiple: 16, ExpectedWidth: 128, ExpectedHeight: 64},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			outputRecorder := NewOutputRecorder()
+			params := &packer.Params{
+				Format:       target.Love,
+				Input:        packer.NewFilenameStream("./fixtures", "button.png"),
+				Output:       outputRecorder,
+				SizeMultiple: test.SizeMultiple,
+			}
+
+			err := packer.Run(context.Background(), params)
+			got := outputRecorder.Got()
+
+			if err != nil {
+				t.Fatalf("Expected run to succeed without error but got '%s'", err)
+			}
+
+			atlasImg, err := png.Decode(got["atlas-1.png"])
+			if err != nil {
+				t.Fatalf("Failed to decode atlas image: %s", err)
+			}
+			if size := atlasImg.Bounds().Size(); size.X != test.ExpectedWidth || size.Y != test.ExpectedHeight {
+				t.Errorf("Expected atlas to be %dx%d but got %dx%d",
+					test.ExpectedWidth, test.ExpectedHeight, size.X, size.Y)
+			}
+
+			expectedString := fmt.Sprintf("quads['button'] = love.graphics.newQuad(%d,%d,%d,%d,%d,%d)",
+				0, 0, buttonWidth, buttonHeight, test.ExpectedWidth, test.ExpectedHeight)
+			if gotStr := got["atlas-1.lua"].String(); !strings.Contains(gotStr, expectedString) {
+				t.Errorf("Expected descriptor to contain the following sub-string\n\n%s\n%s\n\n%s",
+					expectedString, createUnderlineString(expectedString), gotStr)
+			}
+		})
+	}
+}
+
+func TestRunWithUnboundedSizeFitsAllSpritesIntoOneSmallAtlas(t *testing.T) {
+	tests := []struct {
+		Name                string
+		Width, Height       int
+		ExpectedAtlasWidth  int
+		ExpectedAtlasHeight int
+	}{
+		{Name: "Unbounded"},
+		{Name: "UnboundedHeight", Width: 400, ExpectedAtlasWidth: 400},
+		{Name: "UnboundedWidth", Height: 400, ExpectedAtlasHeight: 400},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			outputRecorder := NewOutputRecorder()
+			params := &packer.Params{
+				Format: target.Love,
+				Input:  packer.NewFileStream("./fixtures"),
+				Output: outputRecorder,
+				Width:  test.Width,
+				Height: test.Height,
+			}
+
+			err := packer.Run(context.Background(), params)
+			got := outputRecorder.Got()
+
+			if err != nil {
+				t.Fatalf("Expected run to succeed without error but got '%s'", err)
+			}
+			if _, ok := got["atlas-2.png"]; ok {
+				t.Errorf("Expected all of the sprites to fit in one atlas")
+			}
+
+			atlasImg, err := png.Decode(got["atlas-1.png"])
+			if err != nil {
+				t.Fatalf("Failed to decode atlas image: %s", err)
+			}
+			size := atlasImg.Bounds().Size()
+			if test.ExpectedAtlasWidth > 0 && size.X > test.ExpectedAtlasWidth {
+				t.Errorf("Expected atlas to be no wider than %d but got %d", test.ExpectedAtlasWidth, size.X)
+			}
+			if test.ExpectedAtlasHeight > 0 && size.Y > test.ExpectedAtlasHeight {
+				t.Errorf("Expected atlas to be no taller than %d but got %d", test.ExpectedAtlasHeight, size.Y)
+			}
+			// The sprites cover 3*124*50 + 286*355 + 203*346 pixels,
+			// a good layout should waste less than a third of the atlas
+			if area := size.X * size.Y; area > 3*(3*124*50+286*355+203*346)/2 {
+				t.Errorf("Expected a small atlas but got %dx%d", size.X, size.Y)
+			}
+		})
+	}
+}
+
+func TestRunStopsPackingWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	packs := 0
+	params := &packer.Params{
+		Format: target.Love,
+		Input:  packer.NewFileStream("./fixtures"),
+		Output: NewOutputRecorder(),
+		Algorithm: func(width, height int) packing.Packer {
+			// Cancel once the sprites have been read and packing has begun
+			packs++
+			cancel()
+			return packing.NewBinPacker(width, height)
+		},
+	}
+
+	err := packer.Run(ctx, params)
+	if err != context.Canceled {
+		t.Errorf("Expected run to fail with '%s' but got '%v'", context.Canceled, err)
+	}
+	if packs > 2 {
+		t.Errorf("Expected packing to stop once cancelled but the sprites were packed %d times", packs)
+	}
+}
+
+func TestRunWithUnboundedSizeLimitsTheNumberOfPacks(t *testing.T) {
+	packs := 0
+	params := &packer.Params{
+		Format: target.Love,
+		Input:  packer.NewFileStream("./fixtures"),
+		Output: NewOutputRecorder(),
+		Algorithm: func(width, height int) packing.Packer {
+			packs++
+			return packing.NewMaxRectsPacker(width, height, packing.MaxRectsBestShortSideFit)
+		},
+	}
+
+	if err := packer.Run(context.Background(), params); err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+	if maxPacks := 60; packs > maxPacks {
+		t.Errorf("Expected the sprites to be packed no more than %d times but got %d", maxPacks, packs)
+	}
+}
+
+func TestRunWithUnboundedSizeIsLimitedByMaxTextureSize(t *testing.T) {
+	maxTextureSize := 400
+
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format:         target.Love,
+		Input:          packer.NewFileStream("./fixtures"),
+		Output:         outputRecorder,
+		MaxTextureSize: maxTextureSize,
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+	// Both characters are too large to fit side by side or on top of each other
+	if _, ok := got["atlas-2.png"]; !ok {
+		t.Fatalf("Expected the sprites to be split over multiple atlases")
+	}
+	for filename, buf := range got {
+		if filepath.Ext(filename) != ".png" {
+			continue
+		}
+		atlasImg, err := png.Decode(buf)
+		if err != nil {
+			t.Fatalf("Failed to decode atlas image '%s': %s", filename, err)
+		}
+		if size := atlasImg.Bounds().Size(); size.X > maxTextureSize || size.Y > maxTextureSize {
+			t.Errorf("Expected atlas '%s' to be no larger than %dx%d but got %dx%d",
+				filename, maxTextureSize, maxTextureSize, size.X, size.Y)
+		}
+	}
+}
+
+func TestRunOutputIsIdenticalEveryTime(t *testing.T) {
+	hashOutput := func() map[string]string {
+		outputRecorder := NewOutputRecorder()
+		params := &packer.Params{
+			Format: target.Starling,
+			Input:  packer.NewFileStream("./fixtures"),
+			Output: outputRecorder,
+			Width:  600,
+			Height: 600,
+			Rotate: true,
+		}
+		if err := packer.Run(context.Background(), params); err != nil {
+			t.Fatalf("Expected run to succeed without error but got '%s'", err)
+		}
+
+		hashes := map[string]string{}
+		for filename, buf := range outputRecorder.Got() {
+			hashes[filename] = fmt.Sprintf("%x", sha256.Sum256(buf.Bytes()))
+		}
+		return hashes
+	}
+
+	expected := hashOutput()
+	for i := 0; i < 20; i++ {
+		got := hashOutput()
+		if len(got) != len(expected) {
+			t.Fatalf("Expected %d files to be outputted but got %d", len(expected), len(got))
+		}
+		for filename, hash := range expected {
+			if got[filename] != hash {
+				t.Fatalf("Expected '%s' to be identical on every run but it changed on run %d", filename, i+2)
+			}
+		}
+	}
+}
+
+func TestRunNamesSpritesByTheirPath(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	tests := []struct {
+		Separator     string
+		ExpectedNames []string
+	}{
+		{Separator: "", ExpectedNames: []string{"button", "hud/button", "ui/menu/button"}},
+		{Separator: "_", ExpectedNames: []string{"button", "hud_button", "ui_menu_button"}},
+	}
+
+	for _, test := range tests {
+		outputRecorder := NewOutputRecorder()
+		params := &packer.Params{
+			Format: target.Love,
+			Input: newBytesAssetStream(
+				newImageAsset(t, "button.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+				newImageAsset(t, "hud/button.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+				newImageAsset(t, "ui/menu/button.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+			),
+			Output:        outputRecorder,
+			NameSeparator: test.Separator,
+		}
+
+		err := packer.Run(context.Background(), params)
+		got := outputRecorder.Got()
+
+		if err != nil {
+			t.Fatalf("Expected run to succeed without error but got '%s'", err)
+		}
+
+		gotStr := got["atlas-1.lua"].String()
+		for _, name := range test.ExpectedNames {
+			expectedString := fmt.Sprintf("quads['%s'] =", name)
+			if !strings.Contains(gotStr, expectedString) {
+				t.Errorf("Expected descriptor to contain the following sub-string\n\n%s\n%s\n\n%s",
+					expectedString, createUnderlineString(expectedString), gotStr)
+			}
+		}
+	}
+}
+
+func TestRunWithSpritesOfTheSameNameResultsInError(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	params := &packer.Params{
+		Format: target.Love,
+		Input: newBytesAssetStream(
+			newImageAsset(t, "ui_button.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+			newImageAsset(t, "ui/button.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+		),
+		Output:        NewOutputRecorder(),
+		NameSeparator: "_",
+	}
+
+	err := packer.Run(context.Background(), params)
+	if err == nil {
+		t.Fatalf("Expected run to fail as both sprites are named 'ui_button' but got nil error")
+	}
+	for _, path := range []string{"ui_button.png", "ui/button.png"} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("Expected error to contain the path '%s' but got '%s'", path, err)
+		}
+	}
+}
+
+func TestRunWithSpritesOfTheSameNameListsTheirSourcesInTheError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lovepac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	data, err := ioutil.ReadFile("./fixtures/button.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{filepath.Join(dir, "hud", "button.png"), filepath.Join(dir, "ui", "button.png")}
+	for _, path := range paths {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The glob stream names both assets 'button.png'
+	params := &packer.Params{
+		Format: target.Love,
+		Input:  packer.NewFileGlobStream(filepath.Join(dir, "*", "button.png")),
+		Output: NewOutputRecorder(),
+	}
+
+	err = packer.Run(context.Background(), params)
+	if err == nil {
+		t.Fatalf("Expected run to fail as both sprites are named 'button' but got nil error")
+	}
+	for _, path := range paths {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("Expected error to contain the path '%s' but got '%s'", path, err)
+		}
+	}
+}
+
+func TestRunWithAGroupNamedAfterTheAtlasResultsInError(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	params := &packer.Params{
+		Format: target.Love,
+		Input: newBytesAssetStream(
+			newImageAsset(t, "icon.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+			newImageAsset(t, "tiles/grass.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+		),
+		Output: NewOutputRecorder(),
+		Rules:  []packer.Rule{{Pattern: "tiles/*", Group: packer.DefaultAtlasName}},
+	}
+
+	if err := packer.Run(context.Background(), params); err == nil {
+		t.Errorf("Expected run to fail as the group is named '%s' but got nil error", packer.DefaultAtlasName)
+	}
+}
+
+func TestRunWithEveryAssetInAGroupNamedAfterTheAtlas(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Love,
+		Input: newBytesAssetStream(
+			newImageAsset(t, "tiles/dirt.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+			newImageAsset(t, "tiles/grass.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+		),
+		Output: outputRecorder,
+		Rules:  []packer.Rule{{Pattern: "tiles/*", Group: packer.DefaultAtlasName}},
+	}
+
+	// No ungrouped asset uses the name so nothing collides
+	if err := packer.Run(context.Background(), params); err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+	if _, ok := outputRecorder.Got()[packer.DefaultAtlasName+"-1.lua"]; !ok {
+		t.Errorf("Expected the group to be written to '%s-1.lua'", packer.DefaultAtlasName)
+	}
+}
+
+func TestRunPacksEachGroupIntoItsOwnAtlases(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+
+	outputRecorder := NewOutputRecorder()
+	params := &packer.Params{
+		Format: target.Love,
+		Input: newBytesAssetStream(
+			newImageAsset(t, "logo.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+			newImageAsset(t, "ui/button.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+			newImageAsset(t, "ui/menu/icon.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+			newImageAsset(t, "characters/hero.png", 20, 20, image.Rect(0, 0, 20, 20), red),
+			newImageAsset(t, "characters/villain.png", 20, 20, image.Rect(0, 0, 20, 20), red),
+			newImageAsset(t, "levels/one/tile.png", 10, 10, image.Rect(0, 0, 10, 10), red),
+		),
+		Output:           outputRecorder,
+		Width:            20,
+		Height:           20,
+		GroupByDirectory: true,
+		Rules:            []packer.Rule{{Pattern: "levels/*/*", Group: "tiles"}},
+	}
+
+	err := packer.Run(context.Background(), params)
+	got := outputRecorder.Got()
+
+	if err != nil {
+		t.Fatalf("Expected run to succeed without error but got '%s'", err)
+	}
+
+	expected := map[string][]string{
+		"atlas-1.lua":      {"logo"},
+		"ui-1.lua":         {"ui/button", "ui/menu/icon"},
+		"characters-1.lua": {"characters/hero"},
+		"characters-2.lua": {"characters/villain"},
+		"tiles-1.lua":      {"levels/one/tile"},
+	}
+	for filename, names := range expected {
+		buf, ok := got[filename]
+		if !ok {
+			t.Errorf("Expected file '%s' to be outputted", filename)
+			continue
+		}
+		if n := strings.Count(buf.String(), "quads['"); n != len(names) {
+			t.Errorf("Expected '%s' to contain %d sprites but got %d", filename, len(names), n)
+		}
+		for _, name := range names {
+			if expectedString := fmt.Sprintf("quads['%s'] =", name); !strings.Contains(buf.String(), expectedString) {
+				t.Errorf("Expected '%s' to contain the following sub-string\n\n%s\n%s\n\n%s",
+					filename, expectedString, createUnderlineString(expectedString), buf.String())
+			}
+		}
+	}
+	if len(got) != 2*len(expected) {
+		t.Errorf("Expected %d files to be outputted but got %d", 2*len(expected), len(got))
+	}
+}
+
+type onePerAtlasPacker struct {
+	packed bool
+}
+
+func (p *onePerAtlasPacker) Pack(block packing.Block) error {
+	if p.packed {
+		return packing.ErrOutOfRoom
+	}
+	p.packed = true
+	block.Place(0, 0)
+	return nil
+}
+
+// diagonalPacker places each block below and to the right of the
+// previous block, fitting every block into a single wasteful atlas
+type diagonalPacker struct {
+	width, height int
+	x, y          int
+}
+
+func (p *diagonalPacker) Pack(block packing.Block) error {
+	w, h := block.Size()
+	if p.x+w > p.width || p.y+h > p.height {
+		return packing.ErrOutOfRoom
+	}
+	block.Place(p.x, p.y)
+	p.x, p.y = p.x+w, p.y+h
+	return nil
+}
+
 func createUnderlineString(input string) string {
 	inputLength := len(input)
 	chars := make([]rune, inputLength)
@@ -0,0 +1,244 @@
+package packer
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/RaniSputnik/lovepac/packing"
+)
+
+// maxAtlasSize returns the largest size that an atlas can be,
+// this is the Width and Height params reduced to the largest
+// power of two and/or square size if these are required
+func maxAtlasSize(params *Params) (int, int) {
+	width, height := params.Width, params.Height
+	// A width or height of 0 is unbounded,
+	// only limited by the maximum texture size
+	if width == 0 || width > params.MaxTextureSize {
+		width = params.MaxTextureSize
+	}
+	if height == 0 || height > params.MaxTextureSize {
+		height = params.MaxTextureSize
+	}
+	if params.PowerOfTwo {
+		width, height = floorPowerOfTwo(width), floorPowerOfTwo(height)
+	}
+	if params.Square {
+		if width < height {
+			height = width
+		} else {
+			width = height
+		}
+	}
+	return width, height
+}
+
+// croppedAtlasSize returns the size of the smallest atlas, no larger than
+// the given maximum, that contains the sprites of the atlas and the border
+// padding around them. The size is rounded up to a multiple of SizeMultiple.
+func croppedAtlasSize(a *atlas, params *Params, maxWidth, maxHeight int) (int, int) {
+	width, height := a.bounds()
+	width, height = width+params.borderPadding(), height+params.borderPadding()
+	if params.SizeMultiple > 1 {
+		width = roundUp(width, params.SizeMultiple)
+		height = roundUp(height, params.SizeMultiple)
+	}
+	return clamp(width, 1, maxWidth), clamp(height, 1, maxHeight)
+}
+
+// smallestAtlasSize returns the smallest power of two and/or square size,
+// no larger than the given maximum, that every sprite can be packed into.
+// The sprites must fit into an atlas of the maximum size.
+func smallestAtlasSize(ctx context.Context, sprites []packing.Block, params *Params, algorithm packing.Factory, maxWidth, maxHeight int) (int, int) {
+	fits := fitsAtSize(ctx, sprites, params, algorithm)
+
+	if !params.PowerOfTwo {
+		// There are too many square sizes to try them all, assume that
+		// any square larger than one that fits will also fit
+		side := 1 + sort.Search(maxWidth-1, func(i int) bool { return fits(i+1, i+1) })
+		return side, side
+	}
+
+	for _, size := range powerOfTwoSizes(maxWidth, maxHeight, params.Square) {
+		if fits(size.width, size.height) {
+			return size.width, size.height
+		}
+	}
+	return maxWidth, maxHeight
+}
+
+// unboundedWidths is the most widths that are tried when both the width
+// and height are unbounded, each width takes several packs to try
+const unboundedWidths = 8
+
+// unboundedAtlasSize returns the smallest size, no larger than the given
+// maximum, that every sprite can be packed into when the Width and/or
+// Height params are unbounded. The sprites must fit into an atlas of the
+// maximum size.
+func unboundedAtlasSize(ctx context.Context, sprites []packing.Block, params *Params, algorithm packing.Factory, maxWidth, maxHeight int) (int, int) {
+	fits := fitsAtSize(ctx, sprites, params, algorithm)
+
+	// No bin smaller than the area of the sprites or
+	// narrower than the widest sprite can hold them
+	area, widest, tallest := 0, 1, 1
+	for _, block := range sprites {
+		w, h := block.Size()
+		area += w * h
+		if block.(*sprite).rotatable {
+			w, h = min(w, h), min(w, h)
+		}
+		widest, tallest = max(widest, w), max(tallest, h)
+	}
+	// The bin is smaller than the atlas by the border and padding
+	_, binOffset := binSize(sprites, params, 0, 0)
+
+	// As with square sizes, assume that the sprites will
+	// still fit if the atlas is made taller or wider
+	smallestHeight := func(width int) int {
+		binWidth, _ := binSize(sprites, params, width, 0)
+		low := max(tallest, area/max(binWidth, 1)) - binOffset
+		return smallestFitting(low, maxHeight, func(height int) bool { return fits(width, height) })
+	}
+	smallestWidth := func(height int) int {
+		_, binHeight := binSize(sprites, params, 0, height)
+		low := max(widest, area/max(binHeight, 1)) - binOffset
+		return smallestFitting(low, maxWidth, func(width int) bool { return fits(width, height) })
+	}
+
+	switch {
+	case params.Height != 0:
+		return min(smallestWidth(maxHeight), maxWidth), maxHeight
+	case params.Width != 0:
+		return maxWidth, min(smallestHeight(maxWidth), maxHeight)
+	}
+
+	// Both the width and height are unbounded, try a few widths
+	// starting from the narrowest that could possibly hold the sprites
+	// and keep whichever has the smallest area
+	start := max(widest, int(math.Sqrt(float64(area))))
+	step := max(1, start/4)
+
+	bestWidth, bestHeight := maxWidth, maxHeight
+	for i := 0; i < unboundedWidths && start+i*step <= maxWidth; i++ {
+		width := start + i*step
+		height := smallestHeight(width)
+		if height <= maxHeight && width*height < bestWidth*bestHeight {
+			bestWidth, bestHeight = width, height
+		}
+	}
+	return bestWidth, bestHeight
+}
+
+// smallestFitting returns the smallest n from low to high for which fits
+// returns true, or high+1 if there is none. Once fits returns true it must
+// return true for every larger n. The step between the n that are tried
+// doubles from low so that large sizes are only packed when they are needed.
+func smallestFitting(low, high int, fits func(n int) bool) int {
+	low = max(low, 1)
+	if low > high {
+		return high + 1
+	}
+	tooSmall, n := low-1, low
+	for !fits(n) {
+		if n >= high {
+			return high + 1
+		}
+		tooSmall, n = n, min(high, low+2*(n-low+1))
+	}
+	return tooSmall + 1 + sort.Search(n-tooSmall-1, func(i int) bool { return fits(tooSmall + 1 + i) })
+}
+
+// fitsAtSize returns a function that reports whether every sprite can be
+// packed into an atlas of a given size. Nothing fits once ctx is cancelled.
+func fitsAtSize(ctx context.Context, sprites []packing.Block, params *Params, algorithm packing.Factory) func(width, height int) bool {
+	area := 0
+	for _, block := range sprites {
+		w, h := block.Size()
+		area += w * h
+	}
+	return func(width, height int) bool {
+		binWidth, binHeight := binSize(sprites, params, width, height)
+		// Don't bother packing if the sprites can't possibly fit
+		if area > binWidth*binHeight || ctx.Err() != nil {
+			return false
+		}
+		_, incomplete, err := pack(sprites, params, algorithm, width, height)
+		return err == nil && len(incomplete) == 0
+	}
+}
+
+type atlasSize struct {
+	width, height int
+}
+
+// powerOfTwoSizes returns every power of two size up to the given maximum,
+// smallest area first, preferring the squarest sizes when areas are equal.
+// If square is true only square sizes are returned.
+func powerOfTwoSizes(maxWidth, maxHeight int, square bool) []atlasSize {
+	var sizes []atlasSize
+	for w := 1; w <= maxWidth; w *= 2 {
+		for h := 1; h <= maxHeight; h *= 2 {
+			if !square || w == h {
+				sizes = append(sizes, atlasSize{w, h})
+			}
+		}
+	}
+	sort.SliceStable(sizes, func(i, j int) bool {
+		a, b := sizes[i], sizes[j]
+		if a.width*a.height != b.width*b.height {
+			return a.width*a.height < b.width*b.height
+		}
+		return abs(a.width-a.height) < abs(b.width-b.height)
+	})
+	return sizes
+}
+
+// floorPowerOfTwo returns the largest power of two that is
+// less than or equal to n, or 0 if n is less than one
+func floorPowerOfTwo(n int) int {
+	if n < 1 {
+		return 0
+	}
+	p := 1
+	for p*2 <= n {
+		p *= 2
+	}
+	return p
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// roundUp rounds n up to the nearest multiple of m
+func roundUp(n, m int) int {
+	return (n + m - 1) / m * m
+}
+
+func clamp(n, low, high int) int {
+	if n < low {
+		return low
+	}
+	if n > high {
+		return high
+	}
+	return n
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
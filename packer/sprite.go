@@ -2,6 +2,7 @@ package packer
 
 import (
 	"path"
+	"path/filepath"
 	"strings"
 )
 
@@ -10,26 +11,118 @@ import (
 // was constructed to represent
 type sprite struct {
 	Asset
-	path    string
+	path string
+	// separator replaces the slashes in the path of the sprite to form
+	// the name, eg. "ui/button.png" is named "ui_button" for "_"
+	separator string
+	// group is the name of the atlases that the sprite is packed in,
+	// or empty to pack the sprite in the default atlases
+	group   string
 	x, y    int
 	w, h    int
-	padding int
-	placed  bool
+	extrude int
+
+	// borderPadding is the gap between the sprite and the edge
+	// of the atlas, shapePadding is the gap between sprites
+	borderPadding int
+	shapePadding  int
+
+	// The offset and size of the untrimmed image,
+	// w and h are the size after trimming
+	offsetX, offsetY int
+	sourceW, sourceH int
+
+	rotatable bool
+	rotated   bool
+	placed    bool
+
+	// hash identifies the pixels of the sprite, sprites with
+	// identical pixels are packed once and listed as aliases
+	hash    string
+	aliases []*sprite
+	isAlias bool
+
+	// events reports the first time that the sprite is placed
+	events   *eventLog
+	reported bool
 }
 
 // Implement block interface
 func (s *sprite) Size() (int, int) {
-	return s.w + s.padding, s.h + s.padding
+	return s.w + 2*s.extrude + s.shapePadding, s.h + 2*s.extrude + s.shapePadding
 }
 func (s *sprite) Place(x int, y int) {
-	s.x = x + s.padding
-	s.y = y + s.padding
+	s.x = x + s.borderPadding + s.extrude
+	s.y = y + s.borderPadding + s.extrude
+	s.rotated = false
 	s.placed = true
+	s.report()
+}
+
+// report emits SpritePlaced for the sprite and its aliases the first
+// time that the sprite is placed. Sprites without events are not yet
+// reported, eg. the copies placed when trying every algorithm.
+func (s *sprite) report() {
+	if s.reported || s.events == nil {
+		return
+	}
+	s.reported = true
+	s.events.emit(SpritePlaced, s.Name(), 0)
+	for _, alias := range s.aliases {
+		s.events.emit(SpritePlaced, alias.Name(), 0)
+	}
+}
+
+// Implement rotatable block interface
+func (s *sprite) CanRotate() bool { return s.rotatable }
+func (s *sprite) PlaceRotated(x int, y int) {
+	s.Place(x, y)
+	s.rotated = true
 }
 
 // Used for template rendering
-func (s *sprite) Name() string { return strings.Replace(path.Base(s.path), path.Ext(s.path), "", 1) }
-func (s *sprite) Left() int    { return s.x }
-func (s *sprite) Top() int     { return s.y }
-func (s *sprite) Width() int   { return s.w }
-func (s *sprite) Height() int  { return s.h }
+func (s *sprite) Name() string {
+	slashPath := filepath.ToSlash(s.path)
+	name := strings.TrimSuffix(slashPath, path.Ext(slashPath))
+	return strings.Replace(name, "/", s.separator, -1)
+}
+func (s *sprite) Left() int     { return s.x }
+func (s *sprite) Top() int      { return s.y }
+func (s *sprite) Rotated() bool { return s.rotated }
+
+// Trimmed returns true if transparent pixels
+// were removed from the edges of the image
+func (s *sprite) Trimmed() bool { return s.w != s.sourceW || s.h != s.sourceH }
+
+// OffsetX and OffsetY return the position of the
+// trimmed image within the original image
+func (s *sprite) OffsetX() int { return s.offsetX }
+func (s *sprite) OffsetY() int { return s.offsetY }
+
+// FrameX and FrameY return the position of the original image
+// relative to the trimmed image, as used by Starling
+func (s *sprite) FrameX() int { return -s.offsetX }
+func (s *sprite) FrameY() int { return -s.offsetY }
+
+// SourceWidth and SourceHeight return the
+// size of the image before it was trimmed
+func (s *sprite) SourceWidth() int  { return s.sourceW }
+func (s *sprite) SourceHeight() int { return s.sourceH }
+
+// Width returns the width of the sprite within the atlas,
+// this is the height of the image if the sprite was rotated
+func (s *sprite) Width() int {
+	if s.rotated {
+		return s.h
+	}
+	return s.w
+}
+
+// Height returns the height of the sprite within the atlas,
+// this is the width of the image if the sprite was rotated
+func (s *sprite) Height() int {
+	if s.rotated {
+		return s.w
+	}
+	return s.h
+}
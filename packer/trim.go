@@ -0,0 +1,59 @@
+package packer
+
+import (
+	"image"
+	"image/draw"
+)
+
+// opaqueBounds returns the smallest rectangle that contains all of the
+// pixels in the image that are not fully transparent. If the image is
+// completely transparent a single pixel in the top left is returned.
+func opaqueBounds(img image.Image) image.Rectangle {
+	b := img.Bounds()
+	minX, minY, maxX, maxY := b.Max.X, b.Max.Y, b.Min.X, b.Min.Y
+
+	opaque := func(x, y int) bool {
+		_, _, _, a := img.At(x, y).RGBA()
+		return a != 0
+	}
+	if nrgba, ok := img.(*image.NRGBA); ok {
+		opaque = func(x, y int) bool {
+			return nrgba.Pix[nrgba.PixOffset(x, y)+3] != 0
+		}
+	}
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !opaque(x, y) {
+				continue
+			}
+			if x < minX {
+				minX = x
+			}
+			if x >= maxX {
+				maxX = x + 1
+			}
+			if y < minY {
+				minY = y
+			}
+			maxY = y + 1
+		}
+	}
+
+	if minX >= maxX || minY >= maxY {
+		return image.Rect(b.Min.X, b.Min.Y, b.Min.X+1, b.Min.Y+1)
+	}
+	return image.Rect(minX, minY, maxX, maxY)
+}
+
+// crop returns the part of the image within the given rectangle
+func crop(img image.Image, r image.Rectangle) image.Image {
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(r)
+	}
+	cropped := image.NewNRGBA(r)
+	draw.Draw(cropped, r, img, r.Min, draw.Src)
+	return cropped
+}
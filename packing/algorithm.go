@@ -0,0 +1,94 @@
+package packing
+
+import (
+	"sort"
+	"sync"
+)
+
+// AlgorithmBest is the name used to ask for every registered algorithm
+// to be tried, keeping the tightest layout. It is never registered.
+const AlgorithmBest = "best"
+
+// Factory creates a Packer for a bin with the given width and height.
+type Factory func(width, height int) Packer
+
+var (
+	algorithmsMu sync.RWMutex
+	algorithms   = map[string]Factory{
+		"binpack": func(w, h int) Packer { return NewBinPacker(w, h) },
+		"growing": func(w, h int) Packer { return NewGrowingPacker(w, h) },
+
+		"maxrects-bssf": maxRectsFactory(MaxRectsBestShortSideFit),
+		"maxrects-blsf": maxRectsFactory(MaxRectsBestLongSideFit),
+		"maxrects-baf":  maxRectsFactory(MaxRectsBestAreaFit),
+		"maxrects-bl":   maxRectsFactory(MaxRectsBottomLeft),
+		"maxrects-cp":   maxRectsFactory(MaxRectsContactPoint),
+
+		"skyline":          func(w, h int) Packer { return NewSkylinePacker(w, h, false) },
+		"skyline-wastemap": func(w, h int) Packer { return NewSkylinePacker(w, h, true) },
+
+		"guillotine-baf-slas":   guillotineFactory(GuillotineBestAreaFit, GuillotineSplitShorterLeftover),
+		"guillotine-baf-las":    guillotineFactory(GuillotineBestAreaFit, GuillotineSplitLongerAxis),
+		"guillotine-baf-minas":  guillotineFactory(GuillotineBestAreaFit, GuillotineSplitMinimizeArea),
+		"guillotine-bssf-slas":  guillotineFactory(GuillotineBestShortSideFit, GuillotineSplitShorterLeftover),
+		"guillotine-bssf-las":   guillotineFactory(GuillotineBestShortSideFit, GuillotineSplitLongerAxis),
+		"guillotine-bssf-minas": guillotineFactory(GuillotineBestShortSideFit, GuillotineSplitMinimizeArea),
+		"guillotine-waf-slas":   guillotineFactory(GuillotineWorstAreaFit, GuillotineSplitShorterLeftover),
+		"guillotine-waf-las":    guillotineFactory(GuillotineWorstAreaFit, GuillotineSplitLongerAxis),
+		"guillotine-waf-minas":  guillotineFactory(GuillotineWorstAreaFit, GuillotineSplitMinimizeArea),
+
+		"shelf-next":  shelfFactory(ShelfNextFit),
+		"shelf-first": shelfFactory(ShelfFirstFit),
+		"shelf-best":  shelfFactory(ShelfBestHeightFit),
+	}
+)
+
+func maxRectsFactory(heuristic MaxRectsHeuristic) Factory {
+	return func(w, h int) Packer { return NewMaxRectsPacker(w, h, heuristic) }
+}
+
+func guillotineFactory(choice GuillotineChoice, split GuillotineSplit) Factory {
+	return func(w, h int) Packer { return NewGuillotinePacker(w, h, choice, split, true) }
+}
+
+func shelfFactory(choice ShelfChoice) Factory {
+	return func(w, h int) Packer { return NewShelfPacker(w, h, choice) }
+}
+
+// Register makes a packing algorithm available by the given name.
+// Registering a name that is already in use replaces the existing
+// algorithm.
+func Register(name string, factory Factory) {
+	algorithmsMu.Lock()
+	defer algorithmsMu.Unlock()
+	algorithms[name] = factory
+}
+
+// Unregister removes the packing algorithm with the given name,
+// it does nothing if no such algorithm has been registered.
+func Unregister(name string) {
+	algorithmsMu.Lock()
+	defer algorithmsMu.Unlock()
+	delete(algorithms, name)
+}
+
+// AlgorithmNamed returns the registered packing algorithm with
+// the given name, or nil if no such algorithm has been registered.
+func AlgorithmNamed(name string) Factory {
+	algorithmsMu.RLock()
+	defer algorithmsMu.RUnlock()
+	return algorithms[name]
+}
+
+// Algorithms returns the names of all of the
+// registered packing algorithms in alphabetical order.
+func Algorithms() []string {
+	algorithmsMu.RLock()
+	defer algorithmsMu.RUnlock()
+	names := make([]string, 0, len(algorithms))
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,120 @@
+package packing_test
+
+import (
+	"testing"
+
+	. "github.com/RaniSputnik/lovepac/packing"
+)
+
+func TestAllAlgorithmsPackBlocksWithoutOverlap(t *testing.T) {
+	for _, name := range Algorithms() {
+		t.Run(name, func(t *testing.T) {
+			var blocks []Block
+			for i := 1; i <= 100; i++ {
+				blocks = append(blocks, &TestBlock{w: 5 + (i*37)%60, h: 5 + (i*53)%60})
+			}
+
+			packer := AlgorithmNamed(name)(256, 256)
+			for _, block := range blocks {
+				if err := packer.Pack(block); err != nil && err != ErrOutOfRoom {
+					t.Errorf("Expected packer.Pack to succeed or run out of room but got '%v'", err)
+				}
+			}
+			testBlocksDoNotOverlap(t, blocks, 256, 256)
+		})
+	}
+}
+
+func TestAlgorithmNamedReturnsNilForUnknownAlgorithm(t *testing.T) {
+	if got := AlgorithmNamed("doesnotexist"); got != nil {
+		t.Errorf("Expected AlgorithmNamed to return nil for an unknown algorithm")
+	}
+}
+
+func TestRegisterAddsAlgorithm(t *testing.T) {
+	name := "custom"
+	called := false
+	Register(name, func(w, h int) Packer {
+		called = true
+		return NewBinPacker(w, h)
+	})
+	defer Unregister(name)
+
+	factory := AlgorithmNamed(name)
+	if factory == nil {
+		t.Fatalf("Expected registered algorithm '%s' to be returned by AlgorithmNamed", name)
+	}
+	factory(100, 100)
+	if !called {
+		t.Errorf("Expected the registered factory to be called")
+	}
+
+	found := false
+	for _, got := range Algorithms() {
+		if got == name {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected '%s' to be listed in Algorithms()", name)
+	}
+}
+
+func TestUnregisterRemovesAlgorithm(t *testing.T) {
+	name := "removed"
+	Register(name, func(w, h int) Packer { return NewBinPacker(w, h) })
+	Unregister(name)
+
+	if AlgorithmNamed(name) != nil {
+		t.Errorf("Expected AlgorithmNamed to return nil for an unregistered algorithm")
+	}
+	for _, got := range Algorithms() {
+		if got == name {
+			t.Errorf("Expected '%s' not to be listed in Algorithms()", name)
+		}
+	}
+}
+
+func TestAllAlgorithmsRotateBlocksThatOnlyFitRotated(t *testing.T) {
+	for _, name := range Algorithms() {
+		t.Run(name, func(t *testing.T) {
+			blocks := []Block{
+				&TestBlock{id: "1.png", w: 100, h: 100},
+				// Only fits in the space left by 1.png if it is rotated
+				&TestBlock{id: "2.png", w: 100, h: 50, rotatable: true},
+			}
+
+			packer := AlgorithmNamed(name)(150, 100)
+			for _, block := range blocks {
+				if err := packer.Pack(block); err != nil {
+					t.Errorf("Expected packer.Pack of block '%s' not to return an error but got '%v'",
+						block.(*TestBlock).id, err)
+				}
+			}
+			if !blocks[1].(*TestBlock).rotated {
+				t.Errorf("Expected block '2.png' to be rotated")
+			}
+			testBlocksDoNotOverlap(t, blocks, 150, 100)
+		})
+	}
+}
+
+func TestAllAlgorithmsRotateBlocksThatAreTooLargeUnrotated(t *testing.T) {
+	for _, name := range Algorithms() {
+		t.Run(name, func(t *testing.T) {
+			rotatable := &TestBlock{id: "rotatable.png", w: 200, h: 50, rotatable: true}
+			fixed := &TestBlock{id: "fixed.png", w: 200, h: 50}
+
+			if err := AlgorithmNamed(name)(50, 200).Pack(rotatable); err != nil {
+				t.Errorf("Expected packer.Pack of a rotatable block not to return an error but got '%v'", err)
+			}
+			if !rotatable.rotated {
+				t.Errorf("Expected the rotatable block to be rotated")
+			}
+			if err := AlgorithmNamed(name)(50, 200).Pack(fixed); err != ErrInputTooLarge {
+				t.Errorf("Expected packer.Pack of a block that can not be rotated to return '%v' but got '%v'",
+					ErrInputTooLarge, err)
+			}
+		})
+	}
+}
@@ -23,35 +23,20 @@ func (b *BinPacker) Height() int { return b.root.h }
 // Pack implements the Packer interface
 func (b *BinPacker) Pack(block Block) error {
 	bw, bh := block.Size()
-	if bw > b.root.w || bh > b.root.h {
+	rotatable := canRotate(block)
+	if !fitsWithin(bw, bh, b.root.w, b.root.h, rotatable) {
 		return ErrInputTooLarge
 	}
 
-	if n := b.findNode(b.root, bw, bh); n != nil {
-		b.splitNode(n, bw, bh)
+	if n := b.root.find(bw, bh); n != nil {
+		n.split(bw, bh)
 		block.Place(n.x, n.y)
+	} else if n := b.root.find(bh, bw); rotatable && n != nil {
+		n.split(bh, bw)
+		place(block, n.x, n.y, true)
 	} else {
 		return ErrOutOfRoom
 	}
 
 	return nil
 }
-
-func (b *BinPacker) findNode(root *node, w int, h int) *node {
-	if root.used {
-		if r := b.findNode(root.right, w, h); r != nil {
-			return r
-		}
-		return b.findNode(root.down, w, h)
-	} else if (w <= root.w) && (h <= root.h) {
-		return root
-	} else {
-		return nil
-	}
-}
-
-func (b *BinPacker) splitNode(n *node, w int, h int) {
-	n.used = true
-	n.right = &node{x: n.x + w, y: n.y, w: n.w - w, h: h}
-	n.down = &node{x: n.x, y: n.y + h, w: n.w, h: n.h - h}
-}
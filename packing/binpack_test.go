@@ -62,23 +62,28 @@ func TestBinPackingReturnsErrorIfItRunsOutOfSpace(t *testing.T) {
 }
 
 func TestBinPackingStillContinuesWhenRunOutOfSpace(t *testing.T) {
-	blocks := map[Block]error{
-		&TestBlock{id: "1.png", w: 200, h: 200}: nil,
-		&TestBlock{id: "2.png", w: 200, h: 200}: ErrOutOfRoom,
-		&TestBlock{id: "3.png", w: 100, h: 50}:  nil,
+	// Blocks are packed in order, the second block can not fit
+	// alongside the first but the third block can
+	blocks := []struct {
+		block       *TestBlock
+		expectedErr error
+	}{
+		{&TestBlock{id: "1.png", w: 200, h: 200}, nil},
+		{&TestBlock{id: "2.png", w: 200, h: 200}, ErrOutOfRoom},
+		{&TestBlock{id: "3.png", w: 100, h: 50}, nil},
 	}
 
 	packer := NewBinPacker(300, 300)
-	for block, expectedErr := range blocks {
-		if err := packer.Pack(block); err != expectedErr {
+	for _, b := range blocks {
+		if err := packer.Pack(b.block); err != b.expectedErr {
 			t.Errorf("Expected packer.Pack of block '%s' to return '%v' but got '%v'",
-				block.(*TestBlock).id, expectedErr, err)
+				b.block.id, b.expectedErr, err)
 		}
 	}
 
-	for block, expectedErr := range blocks {
-		testBlock := block.(*TestBlock)
-		expectedToBePlaced := expectedErr == nil
+	for _, b := range blocks {
+		testBlock := b.block
+		expectedToBePlaced := b.expectedErr == nil
 		if testBlock.placeWasCalled != expectedToBePlaced {
 			t.Errorf("Expected block (%s) placed to be '%t', but got '%t'",
 				testBlock.id, expectedToBePlaced, testBlock.placeWasCalled)
@@ -22,6 +22,42 @@ type Block interface {
 	Place(x int, y int)
 }
 
+// RotatableBlock is a Block that may be rotated by 90 degrees
+// if that allows it to be packed more tightly.
+//
+// CanRotate returns true if the block is allowed to be rotated.
+//
+// PlaceRotated is called by the packer instead of Place to indicate
+// that the block has been placed at the given position rotated by
+// 90 degrees, so that it occupies its height horizontally and its
+// width vertically.
+type RotatableBlock interface {
+	Block
+	CanRotate() bool
+	PlaceRotated(x int, y int)
+}
+
+// canRotate returns true if the block may be rotated
+func canRotate(block Block) bool {
+	r, ok := block.(RotatableBlock)
+	return ok && r.CanRotate()
+}
+
+// place calls the appropriate place method on the block
+func place(block Block, x int, y int, rotated bool) {
+	if rotated {
+		block.(RotatableBlock).PlaceRotated(x, y)
+	} else {
+		block.Place(x, y)
+	}
+}
+
+// fitsWithin returns true if a block of the given size fits within a bin
+// of the given width and height, rotating the block if that is allowed
+func fitsWithin(bw, bh, width, height int, rotatable bool) bool {
+	return (bw <= width && bh <= height) || (rotatable && bh <= width && bw <= height)
+}
+
 // Packer is the interface that wraps the Pack method.
 type Packer interface {
 	Pack(block Block) error
@@ -35,3 +71,64 @@ type node struct {
 	right *node
 	down  *node
 }
+
+// find returns the first unused node in the tree
+// that is large enough to hold a block of the given size
+func (n *node) find(w int, h int) *node {
+	if n.used {
+		if r := n.right.find(w, h); r != nil {
+			return r
+		}
+		return n.down.find(w, h)
+	} else if (w <= n.w) && (h <= n.h) {
+		return n
+	} else {
+		return nil
+	}
+}
+
+// split marks the node as used by a block of the given size
+// and divides the remaining space to the right and below it
+func (n *node) split(w int, h int) {
+	n.used = true
+	n.right = &node{x: n.x + w, y: n.y, w: n.w - w, h: h}
+	n.down = &node{x: n.x, y: n.y + h, w: n.w, h: n.h - h}
+}
+
+type rect struct {
+	x, y int
+	w, h int
+}
+
+// intersects returns true if the two rectangles overlap
+func (r rect) intersects(o rect) bool {
+	return r.x < o.x+o.w && o.x < r.x+r.w &&
+		r.y < o.y+o.h && o.y < r.y+r.h
+}
+
+// contains returns true if the rectangle o lies entirely within r
+func (r rect) contains(o rect) bool {
+	return o.x >= r.x && o.y >= r.y &&
+		o.x+o.w <= r.x+r.w && o.y+o.h <= r.y+r.h
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -1,18 +1,76 @@
 package packing_test
 
+import (
+	"testing"
+
+	. "github.com/RaniSputnik/lovepac/packing"
+)
+
 type TestBlock struct {
 	id             string
 	x, y           int
 	w, h           int
 	placeWasCalled bool
+	rotatable      bool
+	rotated        bool
 }
 
 func (b *TestBlock) Size() (int, int) {
 	return b.w, b.h
 }
 
+func (b *TestBlock) Name() string {
+	return b.id
+}
+
 func (b *TestBlock) Place(x int, y int) {
 	b.placeWasCalled = true
+	b.rotated = false
 	b.x = x
 	b.y = y
 }
+
+func (b *TestBlock) CanRotate() bool {
+	return b.rotatable
+}
+
+func (b *TestBlock) PlaceRotated(x int, y int) {
+	b.Place(x, y)
+	b.rotated = true
+}
+
+// placedSize returns the space the block occupies once placed
+func (b *TestBlock) placedSize() (int, int) {
+	if b.rotated {
+		return b.h, b.w
+	}
+	return b.w, b.h
+}
+
+// testBlocksDoNotOverlap checks that every placed block lies within
+// the given bounds and that no two placed blocks overlap.
+func testBlocksDoNotOverlap(t *testing.T, blocks []Block, width, height int) {
+	t.Helper()
+	for i, block := range blocks {
+		a := block.(*TestBlock)
+		if !a.placeWasCalled {
+			continue
+		}
+		aw, ah := a.placedSize()
+		if a.x < 0 || a.y < 0 || a.x+aw > width || a.y+ah > height {
+			t.Errorf("Block (%s) at {%d,%d,%d,%d} is outside the bounds {%d,%d}",
+				a.id, a.x, a.y, aw, ah, width, height)
+		}
+		for _, other := range blocks[i+1:] {
+			b := other.(*TestBlock)
+			if !b.placeWasCalled {
+				continue
+			}
+			bw, bh := b.placedSize()
+			if a.x < b.x+bw && b.x < a.x+aw && a.y < b.y+bh && b.y < a.y+ah {
+				t.Errorf("Block (%s) at {%d,%d,%d,%d} overlaps block (%s) at {%d,%d,%d,%d}",
+					a.id, a.x, a.y, aw, ah, b.id, b.x, b.y, bw, bh)
+			}
+		}
+	}
+}
@@ -1,5 +1,134 @@
 package packing
 
+// GrowingPacker is a packer that starts with a bin the size of the
+// first block and grows the bin right or down as more blocks are
+// packed, keeping the bin as close to square as it can.
+//
+// The packer works best when blocks are packed largest first,
+// see ByMaxSide.
 type GrowingPacker struct {
 	root *node
+
+	maxWidth  int
+	maxHeight int
+}
+
+// NewGrowingPacker returns a packer that will grow
+// up to the given maximum width and height
+func NewGrowingPacker(maxWidth, maxHeight int) *GrowingPacker {
+	return &GrowingPacker{
+		maxWidth:  maxWidth,
+		maxHeight: maxHeight,
+	}
+}
+
+// Size returns the current width and height of the GrowingPacker
+func (g *GrowingPacker) Size() (int, int) {
+	if g.root == nil {
+		return 0, 0
+	}
+	return g.root.w, g.root.h
+}
+
+// Width returns the current width of the GrowingPacker
+func (g *GrowingPacker) Width() int {
+	w, _ := g.Size()
+	return w
+}
+
+// Height returns the current height of the GrowingPacker
+func (g *GrowingPacker) Height() int {
+	_, h := g.Size()
+	return h
+}
+
+// Pack implements the Packer interface
+func (g *GrowingPacker) Pack(block Block) error {
+	bw, bh := block.Size()
+	rotatable := canRotate(block)
+	if !fitsWithin(bw, bh, g.maxWidth, g.maxHeight, rotatable) {
+		return ErrInputTooLarge
+	}
+
+	if g.root == nil {
+		if bw <= g.maxWidth && bh <= g.maxHeight {
+			g.root = &node{x: 0, y: 0, w: bw, h: bh}
+		} else {
+			g.root = &node{x: 0, y: 0, w: bh, h: bw}
+		}
+	}
+
+	// Prefer to fit the block into the existing space
+	// in either orientation before growing
+	rotated := false
+	n := g.root.find(bw, bh)
+	if n == nil && rotatable {
+		n, rotated = g.root.find(bh, bw), true
+	}
+	if n == nil {
+		n, rotated = g.grow(bw, bh), false
+	}
+	if n == nil && rotatable {
+		n, rotated = g.grow(bh, bw), true
+	}
+	if n == nil {
+		return ErrOutOfRoom
+	}
+
+	if rotated {
+		bw, bh = bh, bw
+	}
+	n.split(bw, bh)
+	place(block, n.x, n.y, rotated)
+	return nil
+}
+
+// grow extends the root node either right or down to make room
+// for a block of the given size. The direction is chosen to keep
+// the bin roughly square. Returns nil if the bin can not grow.
+func (g *GrowingPacker) grow(w int, h int) *node {
+	canGrowDown := w <= g.root.w && g.root.h+h <= g.maxHeight
+	canGrowRight := h <= g.root.h && g.root.w+w <= g.maxWidth
+
+	shouldGrowRight := canGrowRight && g.root.h >= g.root.w+w
+	shouldGrowDown := canGrowDown && g.root.w >= g.root.h+h
+
+	switch {
+	case shouldGrowRight:
+		return g.growRight(w, h)
+	case shouldGrowDown:
+		return g.growDown(w, h)
+	case canGrowRight:
+		return g.growRight(w, h)
+	case canGrowDown:
+		return g.growDown(w, h)
+	default:
+		return nil
+	}
+}
+
+func (g *GrowingPacker) growRight(w int, h int) *node {
+	g.root = &node{
+		used:  true,
+		x:     0,
+		y:     0,
+		w:     g.root.w + w,
+		h:     g.root.h,
+		down:  g.root,
+		right: &node{x: g.root.w, y: 0, w: w, h: g.root.h},
+	}
+	return g.root.find(w, h)
+}
+
+func (g *GrowingPacker) growDown(w int, h int) *node {
+	g.root = &node{
+		used:  true,
+		x:     0,
+		y:     0,
+		w:     g.root.w,
+		h:     g.root.h + h,
+		down:  &node{x: 0, y: g.root.h, w: g.root.w, h: h},
+		right: g.root,
+	}
+	return g.root.find(w, h)
 }
@@ -0,0 +1,86 @@
+package packing_test
+
+import (
+	"sort"
+	"testing"
+
+	. "github.com/RaniSputnik/lovepac/packing"
+)
+
+func TestGrowingPackerGrowsToFitBlocks(t *testing.T) {
+	blocks := []Block{
+		&TestBlock{id: "1.png", w: 200, h: 200},
+		&TestBlock{id: "2.png", w: 100, h: 100},
+		&TestBlock{id: "3.png", w: 100, h: 100},
+		&TestBlock{id: "4.png", w: 100, h: 50},
+	}
+
+	packer := NewGrowingPacker(1024, 1024)
+	for _, block := range blocks {
+		if err := packer.Pack(block); err != nil {
+			t.Errorf("Expected that packer.Pack would not return an error but got %s", err.Error())
+		}
+	}
+
+	expectedW, expectedH := 300, 250
+	if w, h := packer.Size(); w != expectedW || h != expectedH {
+		t.Errorf("Expected packer to grow to {%d,%d} but got {%d,%d}", expectedW, expectedH, w, h)
+	}
+	testBlocksDoNotOverlap(t, blocks, packer.Width(), packer.Height())
+}
+
+func TestGrowingPackerStartsEmpty(t *testing.T) {
+	packer := NewGrowingPacker(1024, 1024)
+	if w, h := packer.Size(); w != 0 || h != 0 {
+		t.Errorf("Expected an empty packer to have size {0,0} but got {%d,%d}", w, h)
+	}
+}
+
+func TestGrowingPackerReturnsErrorIfInputBlockWillNeverFit(t *testing.T) {
+	packer := NewGrowingPacker(100, 100)
+	err := packer.Pack(&TestBlock{id: "doesnotfit.png", w: 200, h: 200})
+
+	expected := ErrInputTooLarge
+	if err != expected {
+		t.Errorf("Expected packer.Pack to return '%v' but got '%v'", expected, err)
+	}
+}
+
+func TestGrowingPackerDoesNotGrowBeyondMaximumSize(t *testing.T) {
+	blocks := []Block{
+		&TestBlock{id: "1.png", w: 200, h: 200},
+		&TestBlock{id: "2.png", w: 200, h: 200},
+		&TestBlock{id: "3.png", w: 200, h: 200},
+	}
+
+	packer := NewGrowingPacker(400, 200)
+	err1 := packer.Pack(blocks[0])
+	err2 := packer.Pack(blocks[1])
+	err3 := packer.Pack(blocks[2])
+
+	if err1 != nil || err2 != nil {
+		t.Errorf("Expected the first two blocks to fit but got '%v' and '%v'", err1, err2)
+	}
+	if err3 != ErrOutOfRoom {
+		t.Errorf("Expected packer.Pack of '3.png' to return '%v' but got '%v'", ErrOutOfRoom, err3)
+	}
+	if w, h := packer.Size(); w > 400 || h > 200 {
+		t.Errorf("Expected packer to be no larger than {400,200} but got {%d,%d}", w, h)
+	}
+}
+
+func TestGrowingPackerPacksManyBlocksWithoutOverlap(t *testing.T) {
+	var blocks []Block
+	for i := 1; i <= 50; i++ {
+		blocks = append(blocks, &TestBlock{w: 10 + (i*37)%90, h: 10 + (i*53)%90})
+	}
+	sort.Sort(ByMaxSide(blocks))
+
+	packer := NewGrowingPacker(4096, 4096)
+	for _, block := range blocks {
+		if err := packer.Pack(block); err != nil {
+			t.Errorf("Expected that packer.Pack would not return an error but got %s", err.Error())
+		}
+	}
+	testBlocksDoNotOverlap(t, blocks, packer.Width(), packer.Height())
+}
@@ -0,0 +1,198 @@
+package packing
+
+// GuillotineChoice decides which of the free rectangles
+// a GuillotinePacker will place a block into.
+type GuillotineChoice int
+
+const (
+	// GuillotineBestAreaFit places a block into the smallest
+	// free rectangle that it fits in.
+	GuillotineBestAreaFit GuillotineChoice = iota
+	// GuillotineBestShortSideFit places a block into the free rectangle
+	// that leaves the smallest amount of space on the shorter side.
+	GuillotineBestShortSideFit
+	// GuillotineWorstAreaFit places a block into the largest
+	// free rectangle available.
+	GuillotineWorstAreaFit
+)
+
+// GuillotineSplit decides how the space left over in a free
+// rectangle is divided once a block has been placed into it.
+type GuillotineSplit int
+
+const (
+	// GuillotineSplitShorterLeftover cuts along the axis with
+	// the least space left over.
+	GuillotineSplitShorterLeftover GuillotineSplit = iota
+	// GuillotineSplitLongerAxis cuts along the longer
+	// axis of the free rectangle.
+	GuillotineSplitLongerAxis
+	// GuillotineSplitMinimizeArea cuts so that the smaller of the
+	// two new free rectangles is as small as possible.
+	GuillotineSplitMinimizeArea
+)
+
+// GuillotinePacker is a packer that divides the bin with edge to
+// edge cuts, so that every layout it produces can be reproduced by
+// recursively cutting the bin in two. The BinPacker is a limited
+// special case of this packer.
+type GuillotinePacker struct {
+	width, height int
+	choice        GuillotineChoice
+	split         GuillotineSplit
+	merge         bool
+
+	free []rect
+}
+
+// NewGuillotinePacker returns a packer with the given width and height.
+// Free rectangles are chosen with the given choice rule and divided with
+// the given split rule. If merge is true, neighbouring free rectangles
+// will be joined together where possible.
+func NewGuillotinePacker(width, height int, choice GuillotineChoice, split GuillotineSplit, merge bool) *GuillotinePacker {
+	return &GuillotinePacker{
+		width:  width,
+		height: height,
+		choice: choice,
+		split:  split,
+		merge:  merge,
+		free:   []rect{{x: 0, y: 0, w: width, h: height}},
+	}
+}
+
+// Size returns the width and height of the GuillotinePacker
+func (g *GuillotinePacker) Size() (int, int) { return g.width, g.height }
+
+// Width returns the width of the GuillotinePacker (immutable)
+func (g *GuillotinePacker) Width() int { return g.width }
+
+// Height returns the height of the GuillotinePacker (immutable)
+func (g *GuillotinePacker) Height() int { return g.height }
+
+// Pack implements the Packer interface
+func (g *GuillotinePacker) Pack(block Block) error {
+	bw, bh := block.Size()
+	rotatable := canRotate(block)
+	if !fitsWithin(bw, bh, g.width, g.height, rotatable) {
+		return ErrInputTooLarge
+	}
+
+	i, score, ok := g.find(bw, bh)
+	rotated := false
+	if rotatable {
+		if ri, rscore, rok := g.find(bh, bw); rok && (!ok || rscore < score) {
+			i, rotated, ok = ri, true, true
+		}
+	}
+	if !ok {
+		return ErrOutOfRoom
+	}
+	if rotated {
+		bw, bh = bh, bw
+	}
+
+	f := g.free[i]
+	used := rect{x: f.x, y: f.y, w: bw, h: bh}
+	g.free = append(g.free[:i], g.free[i+1:]...)
+	g.splitFreeRect(f, used)
+	if g.merge {
+		g.mergeFreeRects()
+	}
+
+	place(block, used.x, used.y, rotated)
+	return nil
+}
+
+// find returns the index of the free rectangle that a block of the
+// given size should be placed into according to the choice rule,
+// and the score of that choice (lower is better)
+func (g *GuillotinePacker) find(w int, h int) (int, int, bool) {
+	bestIndex := -1
+	bestScore := 0
+
+	for i, f := range g.free {
+		if w > f.w || h > f.h {
+			continue
+		}
+		var score int
+		switch g.choice {
+		case GuillotineBestShortSideFit:
+			score = min(f.w-w, f.h-h)
+		case GuillotineWorstAreaFit:
+			score = -(f.w*f.h - w*h)
+		default:
+			score = f.w*f.h - w*h
+		}
+		if bestIndex < 0 || score < bestScore {
+			bestIndex = i
+			bestScore = score
+		}
+	}
+
+	return bestIndex, bestScore, bestIndex >= 0
+}
+
+// splitFreeRect divides the space left in f once used has been
+// placed in its top left corner into (at most) two new free rectangles
+func (g *GuillotinePacker) splitFreeRect(f rect, used rect) {
+	leftoverW := f.w - used.w
+	leftoverH := f.h - used.h
+
+	var splitHorizontal bool
+	switch g.split {
+	case GuillotineSplitLongerAxis:
+		splitHorizontal = f.w > f.h
+	case GuillotineSplitMinimizeArea:
+		splitHorizontal = used.w*leftoverH > leftoverW*used.h
+	default:
+		splitHorizontal = leftoverW <= leftoverH
+	}
+
+	bottom := rect{x: f.x, y: f.y + used.h, w: used.w, h: leftoverH}
+	right := rect{x: f.x + used.w, y: f.y, w: leftoverW, h: f.h}
+	if splitHorizontal {
+		bottom.w = f.w
+		right.h = used.h
+	}
+
+	if bottom.w > 0 && bottom.h > 0 {
+		g.free = append(g.free, bottom)
+	}
+	if right.w > 0 && right.h > 0 {
+		g.free = append(g.free, right)
+	}
+}
+
+// mergeFreeRects joins any pair of free rectangles
+// that share a complete edge into a single rectangle
+func (g *GuillotinePacker) mergeFreeRects() {
+	for i := 0; i < len(g.free); i++ {
+		for j := i + 1; j < len(g.free); j++ {
+			a, b := g.free[i], g.free[j]
+			merged := false
+			if a.w == b.w && a.x == b.x {
+				if a.y == b.y+b.h {
+					g.free[i] = rect{x: a.x, y: b.y, w: a.w, h: a.h + b.h}
+					merged = true
+				} else if a.y+a.h == b.y {
+					g.free[i] = rect{x: a.x, y: a.y, w: a.w, h: a.h + b.h}
+					merged = true
+				}
+			} else if a.h == b.h && a.y == b.y {
+				if a.x == b.x+b.w {
+					g.free[i] = rect{x: b.x, y: a.y, w: a.w + b.w, h: a.h}
+					merged = true
+				} else if a.x+a.w == b.x {
+					g.free[i] = rect{x: a.x, y: a.y, w: a.w + b.w, h: a.h}
+					merged = true
+				}
+			}
+			if merged {
+				g.free = append(g.free[:j], g.free[j+1:]...)
+				// The merged rectangle may now share an edge
+				// with a rectangle that has already been checked
+				j = i
+			}
+		}
+	}
+}
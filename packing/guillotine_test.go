@@ -0,0 +1,89 @@
+package packing_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/RaniSputnik/lovepac/packing"
+)
+
+var guillotineChoices = map[string]GuillotineChoice{
+	"BestAreaFit":      GuillotineBestAreaFit,
+	"BestShortSideFit": GuillotineBestShortSideFit,
+	"WorstAreaFit":     GuillotineWorstAreaFit,
+}
+
+var guillotineSplits = map[string]GuillotineSplit{
+	"ShorterLeftover": GuillotineSplitShorterLeftover,
+	"LongerAxis":      GuillotineSplitLongerAxis,
+	"MinimizeArea":    GuillotineSplitMinimizeArea,
+}
+
+func TestGuillotinePackingManyBlocksDoNotOverlap(t *testing.T) {
+	for choiceName, choice := range guillotineChoices {
+		for splitName, split := range guillotineSplits {
+			for _, merge := range []bool{false, true} {
+				t.Run(fmt.Sprintf("%s/%s/Merge=%t", choiceName, splitName, merge), func(t *testing.T) {
+					var blocks []Block
+					for i := 1; i <= 100; i++ {
+						blocks = append(blocks, &TestBlock{w: 5 + (i*37)%60, h: 5 + (i*53)%60})
+					}
+
+					packer := NewGuillotinePacker(256, 256, choice, split, merge)
+					for _, block := range blocks {
+						if err := packer.Pack(block); err != nil && err != ErrOutOfRoom {
+							t.Errorf("Expected packer.Pack to succeed or run out of room but got '%v'", err)
+						}
+					}
+					testBlocksDoNotOverlap(t, blocks, 256, 256)
+				})
+			}
+		}
+	}
+}
+
+func TestGuillotinePackingMergesFreeRectangles(t *testing.T) {
+	for merge, expectLastErr := range map[bool]error{false: ErrOutOfRoom, true: nil} {
+		blocks := []Block{
+			&TestBlock{id: "1.png", w: 50, h: 50},
+			&TestBlock{id: "2.png", w: 50, h: 50},
+			// Only fits if the two free rectangles left
+			// underneath 1.png and 2.png are merged
+			&TestBlock{id: "3.png", w: 100, h: 50},
+		}
+
+		packer := NewGuillotinePacker(100, 100, GuillotineWorstAreaFit, GuillotineSplitLongerAxis, merge)
+		var err error
+		for _, block := range blocks {
+			err = packer.Pack(block)
+		}
+		if err != expectLastErr {
+			t.Errorf("Expected packer.Pack of '3.png' with merge '%t' to return '%v' but got '%v'",
+				merge, expectLastErr, err)
+		}
+		testBlocksDoNotOverlap(t, blocks, 100, 100)
+	}
+}
+
+func TestGuillotinePackingReturnsErrorIfInputBlockWillNeverFit(t *testing.T) {
+	packer := NewGuillotinePacker(100, 100, GuillotineBestAreaFit, GuillotineSplitShorterLeftover, false)
+	err := packer.Pack(&TestBlock{id: "doesnotfit.png", w: 200, h: 200})
+
+	expected := ErrInputTooLarge
+	if err != expected {
+		t.Errorf("Expected packer.Pack to return '%v' but got '%v'", expected, err)
+	}
+}
+
+func TestGuillotinePackingReturnsErrorIfItRunsOutOfSpace(t *testing.T) {
+	packer := NewGuillotinePacker(200, 200, GuillotineBestAreaFit, GuillotineSplitShorterLeftover, true)
+	err1 := packer.Pack(&TestBlock{id: "1.png", w: 200, h: 150})
+	err2 := packer.Pack(&TestBlock{id: "2.png", w: 100, h: 100})
+
+	if err1 != nil {
+		t.Errorf("Expected packer.Pack of '1.png' to fit but got '%v'", err1)
+	}
+	if err2 != ErrOutOfRoom {
+		t.Errorf("Expected packer.Pack of '2.png' to return '%v' but got '%v'", ErrOutOfRoom, err2)
+	}
+}
@@ -0,0 +1,215 @@
+package packing
+
+// MaxRectsHeuristic decides which of the free rectangles
+// a MaxRectsPacker will place a block into.
+type MaxRectsHeuristic int
+
+const (
+	// MaxRectsBestShortSideFit places a block into the free rectangle
+	// that leaves the smallest amount of space on the shorter side.
+	MaxRectsBestShortSideFit MaxRectsHeuristic = iota
+	// MaxRectsBestLongSideFit places a block into the free rectangle
+	// that leaves the smallest amount of space on the longer side.
+	MaxRectsBestLongSideFit
+	// MaxRectsBestAreaFit places a block into the smallest
+	// free rectangle that it fits in.
+	MaxRectsBestAreaFit
+	// MaxRectsBottomLeft places a block as close to the top (lowest y)
+	// and then as far left as possible, tetris style.
+	MaxRectsBottomLeft
+	// MaxRectsContactPoint places a block where it touches as much
+	// of the edge of the bin and the other blocks as possible.
+	MaxRectsContactPoint
+)
+
+// MaxRectsPacker is a packer that keeps track of every maximal
+// free rectangle left in the bin. It is slower than the BinPacker
+// but wastes far less space when blocks are of mixed sizes.
+type MaxRectsPacker struct {
+	width, height int
+	heuristic     MaxRectsHeuristic
+
+	used []rect
+	free []rect
+}
+
+// NewMaxRectsPacker returns a packer with the given width and height
+// that places blocks using the given heuristic
+func NewMaxRectsPacker(width, height int, heuristic MaxRectsHeuristic) *MaxRectsPacker {
+	return &MaxRectsPacker{
+		width:     width,
+		height:    height,
+		heuristic: heuristic,
+		free:      []rect{{x: 0, y: 0, w: width, h: height}},
+	}
+}
+
+// Size returns the width and height of the MaxRectsPacker
+func (m *MaxRectsPacker) Size() (int, int) { return m.width, m.height }
+
+// Width returns the width of the MaxRectsPacker (immutable)
+func (m *MaxRectsPacker) Width() int { return m.width }
+
+// Height returns the height of the MaxRectsPacker (immutable)
+func (m *MaxRectsPacker) Height() int { return m.height }
+
+// Pack implements the Packer interface
+func (m *MaxRectsPacker) Pack(block Block) error {
+	bw, bh := block.Size()
+	rotatable := canRotate(block)
+	if !fitsWithin(bw, bh, m.width, m.height, rotatable) {
+		return ErrInputTooLarge
+	}
+
+	r, rotated, ok := m.findOriented(bw, bh, rotatable)
+	if !ok {
+		return ErrOutOfRoom
+	}
+
+	m.place(r)
+	place(block, r.x, r.y, rotated)
+	return nil
+}
+
+// findOriented returns the best position for a block of the given size,
+// trying the block rotated by 90 degrees as well if it is rotatable
+func (m *MaxRectsPacker) findOriented(w int, h int, rotatable bool) (rect, bool, bool) {
+	r, score1, score2, ok := m.find(w, h)
+	if !rotatable {
+		return r, false, ok
+	}
+	rr, rscore1, rscore2, rok := m.find(h, w)
+	if rok && (!ok || rscore1 < score1 || (rscore1 == score1 && rscore2 < score2)) {
+		return rr, true, true
+	}
+	return r, false, ok
+}
+
+// find returns the best position for a block of the given size
+// and its score according to the heuristic of the packer
+func (m *MaxRectsPacker) find(w int, h int) (rect, int, int, bool) {
+	var best rect
+	found := false
+	bestScore1, bestScore2 := 0, 0
+
+	for _, f := range m.free {
+		if w > f.w || h > f.h {
+			continue
+		}
+		candidate := rect{x: f.x, y: f.y, w: w, h: h}
+		score1, score2 := m.score(f, candidate)
+		if !found || score1 < bestScore1 || (score1 == bestScore1 && score2 < bestScore2) {
+			best = candidate
+			bestScore1, bestScore2 = score1, score2
+			found = true
+		}
+	}
+
+	return best, bestScore1, bestScore2, found
+}
+
+// score rates placing the candidate into the free rectangle f,
+// lower scores are better
+func (m *MaxRectsPacker) score(f rect, candidate rect) (int, int) {
+	leftoverHoriz := abs(f.w - candidate.w)
+	leftoverVert := abs(f.h - candidate.h)
+	shortSide := min(leftoverHoriz, leftoverVert)
+	longSide := max(leftoverHoriz, leftoverVert)
+
+	switch m.heuristic {
+	case MaxRectsBestLongSideFit:
+		return longSide, shortSide
+	case MaxRectsBestAreaFit:
+		return f.w*f.h - candidate.w*candidate.h, shortSide
+	case MaxRectsBottomLeft:
+		return candidate.y + candidate.h, candidate.x
+	case MaxRectsContactPoint:
+		return -m.contactScore(candidate), 0
+	default:
+		return shortSide, longSide
+	}
+}
+
+// contactScore returns the length of the candidate's perimeter
+// that touches either the edge of the bin or a used rectangle
+func (m *MaxRectsPacker) contactScore(c rect) int {
+	score := 0
+	if c.x == 0 || c.x+c.w == m.width {
+		score += c.h
+	}
+	if c.y == 0 || c.y+c.h == m.height {
+		score += c.w
+	}
+	for _, u := range m.used {
+		if u.x == c.x+c.w || u.x+u.w == c.x {
+			score += commonIntervalLength(u.y, u.y+u.h, c.y, c.y+c.h)
+		}
+		if u.y == c.y+c.h || u.y+u.h == c.y {
+			score += commonIntervalLength(u.x, u.x+u.w, c.x, c.x+c.w)
+		}
+	}
+	return score
+}
+
+// place marks the given rectangle as used, splitting
+// any free rectangles that it overlaps
+func (m *MaxRectsPacker) place(used rect) {
+	free := make([]rect, 0, len(m.free)+4)
+	for _, f := range m.free {
+		if !f.intersects(used) {
+			free = append(free, f)
+			continue
+		}
+		free = append(free, splitFreeRect(f, used)...)
+	}
+	m.free = pruneFreeRects(free)
+	m.used = append(m.used, used)
+}
+
+// splitFreeRect returns the maximal rectangles of f
+// that remain once used has been removed from it
+func splitFreeRect(f rect, used rect) []rect {
+	var result []rect
+	if used.x > f.x {
+		result = append(result, rect{x: f.x, y: f.y, w: used.x - f.x, h: f.h})
+	}
+	if used.x+used.w < f.x+f.w {
+		result = append(result, rect{x: used.x + used.w, y: f.y, w: f.x + f.w - used.x - used.w, h: f.h})
+	}
+	if used.y > f.y {
+		result = append(result, rect{x: f.x, y: f.y, w: f.w, h: used.y - f.y})
+	}
+	if used.y+used.h < f.y+f.h {
+		result = append(result, rect{x: f.x, y: used.y + used.h, w: f.w, h: f.y + f.h - used.y - used.h})
+	}
+	return result
+}
+
+// pruneFreeRects removes any rectangles that
+// are contained entirely within another
+func pruneFreeRects(free []rect) []rect {
+	result := make([]rect, 0, len(free))
+	for i, r := range free {
+		redundant := false
+		for j, o := range free {
+			// Where two rectangles are identical, only the first is kept
+			if i != j && o.contains(r) && (r != o || j < i) {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// commonIntervalLength returns the length of the overlap
+// of the intervals [aStart,aEnd] and [bStart,bEnd]
+func commonIntervalLength(aStart, aEnd, bStart, bEnd int) int {
+	if aEnd < bStart || bEnd < aStart {
+		return 0
+	}
+	return min(aEnd, bEnd) - max(aStart, bStart)
+}
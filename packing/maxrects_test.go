@@ -0,0 +1,79 @@
+package packing_test
+
+import (
+	"testing"
+
+	. "github.com/RaniSputnik/lovepac/packing"
+)
+
+var maxRectsHeuristics = map[string]MaxRectsHeuristic{
+	"BestShortSideFit": MaxRectsBestShortSideFit,
+	"BestLongSideFit":  MaxRectsBestLongSideFit,
+	"BestAreaFit":      MaxRectsBestAreaFit,
+	"BottomLeft":       MaxRectsBottomLeft,
+	"ContactPoint":     MaxRectsContactPoint,
+}
+
+func TestMaxRectsPackingFillsBinCompletely(t *testing.T) {
+	for name, heuristic := range maxRectsHeuristics {
+		t.Run(name, func(t *testing.T) {
+			// The BinPacker is unable to fit all of these blocks
+			blocks := []Block{
+				&TestBlock{id: "1.png", w: 150, h: 50},
+				&TestBlock{id: "2.png", w: 50, h: 200},
+				&TestBlock{id: "3.png", w: 150, h: 150},
+			}
+
+			packer := NewMaxRectsPacker(200, 200, heuristic)
+			for _, block := range blocks {
+				if err := packer.Pack(block); err != nil {
+					t.Errorf("Expected packer.Pack of block '%s' not to return an error but got '%v'",
+						block.(*TestBlock).id, err)
+				}
+			}
+			testBlocksDoNotOverlap(t, blocks, 200, 200)
+		})
+	}
+}
+
+func TestMaxRectsPackingManyBlocksDoNotOverlap(t *testing.T) {
+	for name, heuristic := range maxRectsHeuristics {
+		t.Run(name, func(t *testing.T) {
+			var blocks []Block
+			for i := 1; i <= 100; i++ {
+				blocks = append(blocks, &TestBlock{w: 5 + (i*37)%60, h: 5 + (i*53)%60})
+			}
+
+			packer := NewMaxRectsPacker(256, 256, heuristic)
+			for _, block := range blocks {
+				if err := packer.Pack(block); err != nil && err != ErrOutOfRoom {
+					t.Errorf("Expected packer.Pack to succeed or run out of room but got '%v'", err)
+				}
+			}
+			testBlocksDoNotOverlap(t, blocks, 256, 256)
+		})
+	}
+}
+
+func TestMaxRectsPackingReturnsErrorIfInputBlockWillNeverFit(t *testing.T) {
+	packer := NewMaxRectsPacker(100, 100, MaxRectsBestShortSideFit)
+	err := packer.Pack(&TestBlock{id: "doesnotfit.png", w: 200, h: 200})
+
+	expected := ErrInputTooLarge
+	if err != expected {
+		t.Errorf("Expected packer.Pack to return '%v' but got '%v'", expected, err)
+	}
+}
+
+func TestMaxRectsPackingReturnsErrorIfItRunsOutOfSpace(t *testing.T) {
+	packer := NewMaxRectsPacker(200, 200, MaxRectsBestShortSideFit)
+	err1 := packer.Pack(&TestBlock{id: "1.png", w: 200, h: 150})
+	err2 := packer.Pack(&TestBlock{id: "2.png", w: 100, h: 100})
+
+	if err1 != nil {
+		t.Errorf("Expected packer.Pack of '1.png' to fit but got '%v'", err1)
+	}
+	if err2 != ErrOutOfRoom {
+		t.Errorf("Expected packer.Pack of '2.png' to return '%v' but got '%v'", ErrOutOfRoom, err2)
+	}
+}
@@ -0,0 +1,160 @@
+package packing
+
+// ShelfChoice decides which shelf a ShelfPacker
+// will place a block onto.
+type ShelfChoice int
+
+const (
+	// ShelfNextFit only ever places blocks onto the most
+	// recently opened shelf, opening a new shelf when
+	// the block does not fit.
+	ShelfNextFit ShelfChoice = iota
+	// ShelfFirstFit places a block onto the first
+	// shelf that it fits on.
+	ShelfFirstFit
+	// ShelfBestHeightFit places a block onto the shelf
+	// whose height is closest to the height of the block.
+	ShelfBestHeightFit
+)
+
+// ShelfPacker is a packer that arranges blocks left to right in rows
+// (shelves) stacked top to bottom. It is ideal for blocks of similar
+// height, such as font glyphs or animation frames, and produces row
+// major layouts that are easy to inspect.
+type ShelfPacker struct {
+	width, height int
+	choice        ShelfChoice
+
+	shelves []shelf
+}
+
+// shelf is a row of blocks starting at y with height h,
+// x is the position that the next block will be placed at
+type shelf struct {
+	x, y int
+	h    int
+}
+
+// NewShelfPacker returns a packer with the given width and height
+// that places blocks onto shelves using the given choice rule
+func NewShelfPacker(width, height int, choice ShelfChoice) *ShelfPacker {
+	return &ShelfPacker{
+		width:  width,
+		height: height,
+		choice: choice,
+	}
+}
+
+// Size returns the width and height of the ShelfPacker
+func (s *ShelfPacker) Size() (int, int) { return s.width, s.height }
+
+// Width returns the width of the ShelfPacker (immutable)
+func (s *ShelfPacker) Width() int { return s.width }
+
+// Height returns the height of the ShelfPacker (immutable)
+func (s *ShelfPacker) Height() int { return s.height }
+
+// Pack implements the Packer interface
+func (s *ShelfPacker) Pack(block Block) error {
+	bw, bh := block.Size()
+	rotatable := canRotate(block)
+	if !fitsWithin(bw, bh, s.width, s.height, rotatable) {
+		return ErrInputTooLarge
+	}
+
+	i, rotated, ok := s.find(bw, bh, rotatable)
+	if !ok {
+		// New shelves are opened with the block lying flat
+		// where possible so that the shelf is as short as it can be
+		rotated = rotatable && (bw > s.width || (bh > bw && bh <= s.width))
+		if rotated {
+			i, ok = s.open(bw)
+		} else {
+			i, ok = s.open(bh)
+		}
+	}
+	if !ok {
+		return ErrOutOfRoom
+	}
+	if rotated {
+		bw, bh = bh, bw
+	}
+
+	sh := &s.shelves[i]
+	x, y := sh.x, sh.y
+	sh.x += bw
+	// Only the last shelf can grow, it has no shelf on top of it
+	sh.h = max(sh.h, bh)
+
+	place(block, x, y, rotated)
+	return nil
+}
+
+// find returns the index of the existing shelf that a block of the
+// given size should be placed on and whether it should be rotated
+func (s *ShelfPacker) find(w int, h int, rotatable bool) (int, bool, bool) {
+	fits := func(i int) (bool, bool) {
+		if s.fits(i, w, h) {
+			return false, true
+		}
+		return true, rotatable && s.fits(i, h, w)
+	}
+
+	switch s.choice {
+	case ShelfNextFit:
+		last := len(s.shelves) - 1
+		if last < 0 {
+			return -1, false, false
+		}
+		rotated, ok := fits(last)
+		return last, rotated, ok
+	case ShelfFirstFit:
+		for i := range s.shelves {
+			if rotated, ok := fits(i); ok {
+				return i, rotated, true
+			}
+		}
+		return -1, false, false
+	default:
+		bestIndex, bestScore, bestRotated := -1, 0, false
+		for i, sh := range s.shelves {
+			if s.fits(i, w, h) {
+				if score := abs(sh.h - h); bestIndex < 0 || score < bestScore {
+					bestIndex, bestScore, bestRotated = i, score, false
+				}
+			}
+			if rotatable && s.fits(i, h, w) {
+				if score := abs(sh.h - w); bestIndex < 0 || score < bestScore {
+					bestIndex, bestScore, bestRotated = i, score, true
+				}
+			}
+		}
+		return bestIndex, bestRotated, bestIndex >= 0
+	}
+}
+
+// fits returns true if a block of the given size fits on shelf i
+func (s *ShelfPacker) fits(i int, w int, h int) bool {
+	sh := s.shelves[i]
+	if sh.x+w > s.width {
+		return false
+	}
+	if h <= sh.h {
+		return true
+	}
+	return i == len(s.shelves)-1 && sh.y+h <= s.height
+}
+
+// open creates a new shelf of the given height
+// on top of the existing shelves
+func (s *ShelfPacker) open(h int) (int, bool) {
+	y := 0
+	if n := len(s.shelves); n > 0 {
+		y = s.shelves[n-1].y + s.shelves[n-1].h
+	}
+	if y+h > s.height {
+		return -1, false
+	}
+	s.shelves = append(s.shelves, shelf{x: 0, y: y, h: h})
+	return len(s.shelves) - 1, true
+}
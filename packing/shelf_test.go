@@ -0,0 +1,95 @@
+package packing_test
+
+import (
+	"testing"
+
+	. "github.com/RaniSputnik/lovepac/packing"
+)
+
+var shelfChoices = map[string]ShelfChoice{
+	"NextFit":       ShelfNextFit,
+	"FirstFit":      ShelfFirstFit,
+	"BestHeightFit": ShelfBestHeightFit,
+}
+
+func TestShelfPackingGlyphsFillBinCompletely(t *testing.T) {
+	for name, choice := range shelfChoices {
+		t.Run(name, func(t *testing.T) {
+			var blocks []Block
+			for i := 0; i < 16; i++ {
+				blocks = append(blocks, &TestBlock{w: 25, h: 25})
+			}
+
+			packer := NewShelfPacker(100, 100, choice)
+			for _, block := range blocks {
+				if err := packer.Pack(block); err != nil {
+					t.Errorf("Expected that packer.Pack would not return an error but got %s", err.Error())
+				}
+			}
+			testBlocksDoNotOverlap(t, blocks, 100, 100)
+		})
+	}
+}
+
+func TestShelfPackingManyBlocksDoNotOverlap(t *testing.T) {
+	for name, choice := range shelfChoices {
+		t.Run(name, func(t *testing.T) {
+			var blocks []Block
+			for i := 1; i <= 100; i++ {
+				blocks = append(blocks, &TestBlock{w: 5 + (i*37)%60, h: 5 + (i*53)%60})
+			}
+
+			packer := NewShelfPacker(256, 256, choice)
+			for _, block := range blocks {
+				if err := packer.Pack(block); err != nil && err != ErrOutOfRoom {
+					t.Errorf("Expected packer.Pack to succeed or run out of room but got '%v'", err)
+				}
+			}
+			testBlocksDoNotOverlap(t, blocks, 256, 256)
+		})
+	}
+}
+
+func TestShelfPackingPlacesBlocksOnMatchingShelves(t *testing.T) {
+	tall := &TestBlock{id: "tall.png", w: 60, h: 50}
+	short := &TestBlock{id: "short.png", w: 60, h: 20}
+	tallAgain := &TestBlock{id: "tall_again.png", w: 30, h: 50}
+	shortAgain := &TestBlock{id: "short_again.png", w: 30, h: 20}
+
+	packer := NewShelfPacker(100, 100, ShelfBestHeightFit)
+	for _, block := range []Block{tall, short, tallAgain, shortAgain} {
+		if err := packer.Pack(block); err != nil {
+			t.Errorf("Expected packer.Pack of block '%s' not to return an error but got '%v'", block.(*TestBlock).id, err)
+		}
+	}
+
+	if tallAgain.y != tall.y {
+		t.Errorf("Expected '%s' to share a shelf with '%s'", tallAgain.id, tall.id)
+	}
+	if shortAgain.y != short.y {
+		t.Errorf("Expected '%s' to share a shelf with '%s'", shortAgain.id, short.id)
+	}
+}
+
+func TestShelfPackingReturnsErrorIfInputBlockWillNeverFit(t *testing.T) {
+	packer := NewShelfPacker(100, 100, ShelfFirstFit)
+	err := packer.Pack(&TestBlock{id: "doesnotfit.png", w: 200, h: 200})
+
+	expected := ErrInputTooLarge
+	if err != expected {
+		t.Errorf("Expected packer.Pack to return '%v' but got '%v'", expected, err)
+	}
+}
+
+func TestShelfPackingReturnsErrorIfItRunsOutOfSpace(t *testing.T) {
+	packer := NewShelfPacker(200, 200, ShelfFirstFit)
+	err1 := packer.Pack(&TestBlock{id: "1.png", w: 200, h: 150})
+	err2 := packer.Pack(&TestBlock{id: "2.png", w: 100, h: 100})
+
+	if err1 != nil {
+		t.Errorf("Expected packer.Pack of '1.png' to fit but got '%v'", err1)
+	}
+	if err2 != ErrOutOfRoom {
+		t.Errorf("Expected packer.Pack of '2.png' to return '%v' but got '%v'", ErrOutOfRoom, err2)
+	}
+}
@@ -0,0 +1,185 @@
+package packing
+
+// SkylinePacker is a packer that tracks only the top edge (the skyline)
+// of the blocks placed so far and places each new block as low and as
+// far left on the skyline as possible. It is much faster than the
+// MaxRectsPacker when packing thousands of small blocks.
+//
+// The gaps that are left underneath the skyline can optionally be
+// recorded in a waste map and reused for blocks that fit inside them.
+type SkylinePacker struct {
+	width, height int
+	line          []skylineNode
+
+	wasteMap *MaxRectsPacker
+}
+
+// skylineNode is a horizontal segment of the skyline
+// that starts at x, y and extends w to the right
+type skylineNode struct {
+	x, y int
+	w    int
+}
+
+// NewSkylinePacker returns a packer with the given width and height.
+// If useWasteMap is true the gaps left under the skyline will be
+// reused for blocks that fit inside them.
+func NewSkylinePacker(width, height int, useWasteMap bool) *SkylinePacker {
+	s := &SkylinePacker{
+		width:  width,
+		height: height,
+		line:   []skylineNode{{x: 0, y: 0, w: width}},
+	}
+	if useWasteMap {
+		// The waste map starts with no free space, gaps
+		// are added as they are created under the skyline
+		s.wasteMap = &MaxRectsPacker{
+			width:     width,
+			height:    height,
+			heuristic: MaxRectsBestShortSideFit,
+		}
+	}
+	return s
+}
+
+// Size returns the width and height of the SkylinePacker
+func (s *SkylinePacker) Size() (int, int) { return s.width, s.height }
+
+// Width returns the width of the SkylinePacker (immutable)
+func (s *SkylinePacker) Width() int { return s.width }
+
+// Height returns the height of the SkylinePacker (immutable)
+func (s *SkylinePacker) Height() int { return s.height }
+
+// Pack implements the Packer interface
+func (s *SkylinePacker) Pack(block Block) error {
+	bw, bh := block.Size()
+	rotatable := canRotate(block)
+	if !fitsWithin(bw, bh, s.width, s.height, rotatable) {
+		return ErrInputTooLarge
+	}
+
+	if s.wasteMap != nil {
+		if r, rotated, ok := s.wasteMap.findOriented(bw, bh, rotatable); ok {
+			s.wasteMap.place(r)
+			place(block, r.x, r.y, rotated)
+			return nil
+		}
+	}
+
+	i, r, bottom, width, ok := s.find(bw, bh)
+	rotated := false
+	if rotatable {
+		ri, rr, rbottom, rwidth, rok := s.find(bh, bw)
+		if rok && (!ok || rbottom < bottom || (rbottom == bottom && rwidth < width)) {
+			i, r, rotated, ok = ri, rr, true, true
+		}
+	}
+	if !ok {
+		return ErrOutOfRoom
+	}
+
+	if s.wasteMap != nil {
+		s.addWaste(i, r)
+	}
+	s.addLevel(i, r)
+	place(block, r.x, r.y, rotated)
+	return nil
+}
+
+// find returns the index of the skyline node that a block of
+// the given size should be placed on and the resulting position.
+// The lowest position is preferred, then the narrowest node.
+// The bottom of the block and the width of the node are
+// also returned so that positions can be compared.
+func (s *SkylinePacker) find(w int, h int) (int, rect, int, int, bool) {
+	bestIndex := -1
+	bestBottom, bestWidth := 0, 0
+	var best rect
+
+	for i := range s.line {
+		y, ok := s.fits(i, w, h)
+		if !ok {
+			continue
+		}
+		bottom := y + h
+		if bestIndex < 0 || bottom < bestBottom || (bottom == bestBottom && s.line[i].w < bestWidth) {
+			bestIndex = i
+			bestBottom, bestWidth = bottom, s.line[i].w
+			best = rect{x: s.line[i].x, y: y, w: w, h: h}
+		}
+	}
+
+	return bestIndex, best, bestBottom, bestWidth, bestIndex >= 0
+}
+
+// fits returns the y position at which a block of the given
+// size would rest if its left edge was aligned with node i
+func (s *SkylinePacker) fits(i int, w int, h int) (int, bool) {
+	x := s.line[i].x
+	if x+w > s.width {
+		return 0, false
+	}
+	y := 0
+	for widthLeft := w; widthLeft > 0; i++ {
+		y = max(y, s.line[i].y)
+		if y+h > s.height {
+			return 0, false
+		}
+		widthLeft -= s.line[i].w
+	}
+	return y, true
+}
+
+// addWaste records the gaps between the skyline and the
+// underside of the rectangle r in the waste map
+func (s *SkylinePacker) addWaste(i int, r rect) {
+	right := r.x + r.w
+	for ; i < len(s.line) && s.line[i].x < right; i++ {
+		n := s.line[i]
+		if n.y >= r.y {
+			continue
+		}
+		left := n.x
+		width := min(right, n.x+n.w) - left
+		s.wasteMap.free = append(s.wasteMap.free, rect{x: left, y: n.y, w: width, h: r.y - n.y})
+	}
+}
+
+// addLevel raises the skyline to the top of the rectangle r
+// which has been placed with its left edge on node i
+func (s *SkylinePacker) addLevel(i int, r rect) {
+	level := skylineNode{x: r.x, y: r.y + r.h, w: r.w}
+	s.line = append(s.line, skylineNode{})
+	copy(s.line[i+1:], s.line[i:])
+	s.line[i] = level
+
+	// Shrink or remove the nodes that are now covered by the new level
+	for j := i + 1; j < len(s.line); {
+		prev := s.line[j-1]
+		overlap := prev.x + prev.w - s.line[j].x
+		if overlap <= 0 {
+			break
+		}
+		s.line[j].x += overlap
+		s.line[j].w -= overlap
+		if s.line[j].w > 0 {
+			break
+		}
+		s.line = append(s.line[:j], s.line[j+1:]...)
+	}
+
+	s.merge()
+}
+
+// merge combines neighbouring skyline nodes at the same height
+func (s *SkylinePacker) merge() {
+	for i := 0; i < len(s.line)-1; {
+		if s.line[i].y == s.line[i+1].y {
+			s.line[i].w += s.line[i+1].w
+			s.line = append(s.line[:i+1], s.line[i+2:]...)
+		} else {
+			i++
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package packing_test
+
+import (
+	"testing"
+
+	. "github.com/RaniSputnik/lovepac/packing"
+)
+
+func TestSkylinePackingManyBlocksDoNotOverlap(t *testing.T) {
+	for name, useWasteMap := range map[string]bool{"WithoutWasteMap": false, "WithWasteMap": true} {
+		t.Run(name, func(t *testing.T) {
+			var blocks []Block
+			for i := 1; i <= 100; i++ {
+				blocks = append(blocks, &TestBlock{w: 5 + (i*37)%60, h: 5 + (i*53)%60})
+			}
+
+			packer := NewSkylinePacker(256, 256, useWasteMap)
+			for _, block := range blocks {
+				if err := packer.Pack(block); err != nil && err != ErrOutOfRoom {
+					t.Errorf("Expected packer.Pack to succeed or run out of room but got '%v'", err)
+				}
+			}
+			testBlocksDoNotOverlap(t, blocks, 256, 256)
+		})
+	}
+}
+
+func TestSkylinePackingReusesWasteUnderTheSkyline(t *testing.T) {
+	newBlocks := func() []Block {
+		return []Block{
+			&TestBlock{id: "1.png", w: 50, h: 60},
+			&TestBlock{id: "2.png", w: 50, h: 20},
+			&TestBlock{id: "3.png", w: 100, h: 30},
+			// Only fits in the gap left under 3.png
+			&TestBlock{id: "4.png", w: 50, h: 40},
+		}
+	}
+
+	for useWasteMap, expectLastErr := range map[bool]error{false: ErrOutOfRoom, true: nil} {
+		blocks := newBlocks()
+		packer := NewSkylinePacker(100, 100, useWasteMap)
+		var err error
+		for _, block := range blocks {
+			err = packer.Pack(block)
+		}
+		if err != expectLastErr {
+			t.Errorf("Expected packer.Pack of '4.png' with waste map '%t' to return '%v' but got '%v'",
+				useWasteMap, expectLastErr, err)
+		}
+		testBlocksDoNotOverlap(t, blocks, 100, 100)
+	}
+}
+
+func TestSkylinePackingReturnsErrorIfInputBlockWillNeverFit(t *testing.T) {
+	packer := NewSkylinePacker(100, 100, false)
+	err := packer.Pack(&TestBlock{id: "doesnotfit.png", w: 200, h: 200})
+
+	expected := ErrInputTooLarge
+	if err != expected {
+		t.Errorf("Expected packer.Pack to return '%v' but got '%v'", expected, err)
+	}
+}
+
+func TestSkylinePackingReturnsErrorIfItRunsOutOfSpace(t *testing.T) {
+	packer := NewSkylinePacker(200, 200, true)
+	err1 := packer.Pack(&TestBlock{id: "1.png", w: 200, h: 150})
+	err2 := packer.Pack(&TestBlock{id: "2.png", w: 100, h: 100})
+
+	if err1 != nil {
+		t.Errorf("Expected packer.Pack of '1.png' to fit but got '%v'", err1)
+	}
+	if err2 != ErrOutOfRoom {
+		t.Errorf("Expected packer.Pack of '2.png' to return '%v' but got '%v'", ErrOutOfRoom, err2)
+	}
+}
@@ -1,11 +1,15 @@
 package packing
 
 import (
-	"math"
+	"sort"
+	"strings"
 )
 
 // ByArea implements sort Interface for []Block
 // based on the Area of each block.
+//
+// All of the sorters that compare block sizes order blocks
+// of equal size by name, so that the order is reproducible.
 type ByArea []Block
 
 func (a ByArea) Len() int      { return len(a) }
@@ -13,7 +17,7 @@ func (a ByArea) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a ByArea) Less(i, j int) bool {
 	iw, ih := a[i].Size()
 	jw, jh := a[j].Size()
-	return iw*ih > jw*jh
+	return largerThenByName(iw*ih, jw*jh, a[i], a[j])
 }
 
 // ByMaxSide implements sort interface for []Block
@@ -26,5 +30,130 @@ func (a ByMaxSide) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a ByMaxSide) Less(i, j int) bool {
 	wi, hi := a[i].Size()
 	wj, hj := a[j].Size()
-	return math.Max(float64(wi), float64(hi)) > math.Max(float64(wj), float64(hj))
+	return largerThenByName(max(wi, hi), max(wj, hj), a[i], a[j])
+}
+
+// ByWidth implements sort interface for []Block
+// by comparing the width of each block
+type ByWidth []Block
+
+func (a ByWidth) Len() int      { return len(a) }
+func (a ByWidth) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a ByWidth) Less(i, j int) bool {
+	wi, _ := a[i].Size()
+	wj, _ := a[j].Size()
+	return largerThenByName(wi, wj, a[i], a[j])
+}
+
+// ByHeight implements sort interface for []Block
+// by comparing the height of each block
+type ByHeight []Block
+
+func (a ByHeight) Len() int      { return len(a) }
+func (a ByHeight) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a ByHeight) Less(i, j int) bool {
+	_, hi := a[i].Size()
+	_, hj := a[j].Size()
+	return largerThenByName(hi, hj, a[i], a[j])
+}
+
+// ByPerimeter implements sort interface for []Block
+// by comparing the perimeter of each block
+type ByPerimeter []Block
+
+func (a ByPerimeter) Len() int      { return len(a) }
+func (a ByPerimeter) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a ByPerimeter) Less(i, j int) bool {
+	wi, hi := a[i].Size()
+	wj, hj := a[j].Size()
+	return largerThenByName(wi+hi, wj+hj, a[i], a[j])
+}
+
+// ByName implements sort interface for []Block
+// by comparing the names of each block alphabetically.
+// Blocks without a Name method are treated as having
+// an empty name.
+type ByName []Block
+
+func (a ByName) Len() int           { return len(a) }
+func (a ByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a ByName) Less(i, j int) bool { return blockName(a[i]) < blockName(a[j]) }
+
+// largerThenByName returns true if the key of block i is larger
+// than the key of block j, or if the keys are equal and the name
+// of block i comes first alphabetically
+func largerThenByName(keyI, keyJ int, i, j Block) bool {
+	if keyI != keyJ {
+		return keyI > keyJ
+	}
+	return blockName(i) < blockName(j)
+}
+
+func blockName(b Block) string {
+	if named, ok := b.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return ""
+}
+
+// SortOrder arranges blocks into the order that they will be packed.
+type SortOrder func(blocks []Block)
+
+const reverseSuffix = "-reverse"
+
+var sorters = map[string]func([]Block) sort.Interface{
+	"area":      func(b []Block) sort.Interface { return ByArea(b) },
+	"max-side":  func(b []Block) sort.Interface { return ByMaxSide(b) },
+	"width":     func(b []Block) sort.Interface { return ByWidth(b) },
+	"height":    func(b []Block) sort.Interface { return ByHeight(b) },
+	"perimeter": func(b []Block) sort.Interface { return ByPerimeter(b) },
+	"name":      func(b []Block) sort.Interface { return ByName(b) },
+}
+
+// SortOrderNamed returns the sort order with the given name, or nil if
+// there is no such sort order. Valid names are area, max-side, width,
+// height and perimeter (largest first), name (alphabetical) and none
+// (the order given). Each of these can be given the suffix "-reverse"
+// to reverse the order.
+func SortOrderNamed(name string) SortOrder {
+	reverse := strings.HasSuffix(name, reverseSuffix)
+	name = strings.TrimSuffix(name, reverseSuffix)
+
+	if name == "none" {
+		if reverse {
+			return reverseOrder
+		}
+		return func([]Block) {}
+	}
+
+	sorter, ok := sorters[name]
+	if !ok {
+		return nil
+	}
+
+	return func(blocks []Block) {
+		data := sorter(blocks)
+		if reverse {
+			data = sort.Reverse(data)
+		}
+		sort.Stable(data)
+	}
+}
+
+// reverseOrder reverses the order that the blocks were given in
+func reverseOrder(blocks []Block) {
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+}
+
+// SortOrders returns the names of all of the
+// available sort orders in alphabetical order.
+func SortOrders() []string {
+	names := []string{"none", "none" + reverseSuffix}
+	for name := range sorters {
+		names = append(names, name, name+reverseSuffix)
+	}
+	sort.Strings(names)
+	return names
 }
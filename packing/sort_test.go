@@ -44,3 +44,77 @@ func TestSortByMaxSide(t *testing.T) {
 		}
 	}
 }
+
+func TestSortByWidthHeightPerimeterAndName(t *testing.T) {
+	tests := map[string]struct {
+		sort     func([]Block) sort.Interface
+		expected []string
+	}{
+		"Width":     {func(b []Block) sort.Interface { return ByWidth(b) }, []string{"5", "1", "2", "3", "4"}},
+		"Height":    {func(b []Block) sort.Interface { return ByHeight(b) }, []string{"4", "1", "5", "2", "3"}},
+		"Perimeter": {func(b []Block) sort.Interface { return ByPerimeter(b) }, []string{"5", "4", "1", "2", "3"}},
+		"Name":      {func(b []Block) sort.Interface { return ByName(b) }, []string{"1", "2", "3", "4", "5"}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			blocks := []Block{
+				&TestBlock{id: "4", w: 20, h: 600},
+				&TestBlock{id: "2", w: 100, h: 110},
+				&TestBlock{id: "5", w: 512, h: 190},
+				&TestBlock{id: "1", w: 200, h: 200},
+				&TestBlock{id: "3", w: 90, h: 50},
+			}
+
+			sort.Sort(test.sort(blocks))
+
+			for i := range blocks {
+				got := blocks[i].(*TestBlock)
+				if got.id != test.expected[i] {
+					t.Errorf("Expected '%s' at index %d, got '%s'", test.expected[i], i, got.id)
+				}
+			}
+		})
+	}
+}
+
+func TestSortOrderNamed(t *testing.T) {
+	tests := map[string][]string{
+		"area":         {"5", "1", "4", "2", "3"},
+		"area-reverse": {"3", "2", "4", "1", "5"},
+		"name-reverse": {"5", "4", "3", "2", "1"},
+		"none":         {"2", "5", "1", "3", "4"},
+		"none-reverse": {"4", "3", "1", "5", "2"},
+	}
+
+	for name, expected := range tests {
+		t.Run(name, func(t *testing.T) {
+			blocks := []Block{
+				&TestBlock{id: "2", w: 100, h: 100},
+				&TestBlock{id: "5", w: 512, h: 200},
+				&TestBlock{id: "1", w: 200, h: 200},
+				&TestBlock{id: "3", w: 100, h: 50},
+				&TestBlock{id: "4", w: 20, h: 600},
+			}
+
+			SortOrderNamed(name)(blocks)
+
+			for i := range blocks {
+				got := blocks[i].(*TestBlock)
+				if got.id != expected[i] {
+					t.Errorf("Expected '%s' at index %d, got '%s'", expected[i], i, got.id)
+				}
+			}
+		})
+	}
+
+	for _, name := range SortOrders() {
+		if SortOrderNamed(name) == nil {
+			t.Errorf("Expected listed sort order '%s' to be returned by SortOrderNamed", name)
+		}
+	}
+
+	if SortOrderNamed("doesnotexist") != nil {
+		t.Errorf("Expected SortOrderNamed to return nil for an unknown sort order")
+	}
+}
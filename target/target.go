@@ -17,8 +17,16 @@ type Format struct {
 	// used when the descriptor file is written to
 	// the file system.
 	Ext string
-
-	// TODO add features supported (eg. trimming, rotation etc)
+	// SupportsRotation indicates that the template
+	// records which sprites have been rotated, only
+	// formats that support rotation may have sprites
+	// rotated when packed.
+	SupportsRotation bool
+	// SupportsTrimming indicates that the template
+	// records the offset and original size of sprites
+	// that have had transparent edges trimmed, only
+	// formats that support trimming may be trimmed.
+	SupportsTrimming bool
 }
 
 // IsValid checks that a format has a valid template
@@ -29,11 +37,11 @@ func (f Format) IsValid() bool {
 
 var (
 	// Unknown format, should used for error responses
-	Unknown = Format{"unknown", nil, ""}
+	Unknown = Format{Name: "unknown"}
 	// Love format for the love2d game engine
-	Love = Format{"love", loveTemplate, "lua"}
+	Love = Format{Name: "love", Template: loveTemplate, Ext: "lua"}
 	// Starling format for the Starling game engine
-	Starling = Format{"starling", starlingTemplate, "xml"}
+	Starling = Format{Name: "starling", Template: starlingTemplate, Ext: "xml", SupportsRotation: true, SupportsTrimming: true}
 )
 
 var allFormats = []Format{Love, Starling}
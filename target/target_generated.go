@@ -1,5 +1,5 @@
 // Code generated by go generate; DO NOT EDIT.
-// This file was generated by robots at 2017-09-08 19:24:49.178047 +0100 BST
+// This file was generated by robots at 2026-10-18 10:59:42.384954325 +0000 UTC
 // TODO add the commit hash in here too
 
 package target
@@ -18,7 +18,8 @@ return quads
 
 var starlingTemplate = template.Must(template.New("starling").Parse(`<TextureAtlas imagePath="{{.ImageFilename}}">
 {{- range .Sprites}}
-    <SubTexture name="{{.Name}}" x="{{.Left}}" y="{{.Top}}" width="{{.Width}}" height="{{.Height}}"/>
+    <SubTexture name="{{.Name}}" x="{{.Left}}" y="{{.Top}}" width="{{.Width}}" height="{{.Height}}"{{if .Rotated}} rotated="true"{{end}}
+        {{- if .Trimmed}} frameX="{{.FrameX}}" frameY="{{.FrameY}}" frameWidth="{{.SourceWidth}}" frameHeight="{{.SourceHeight}}"{{end}}/>
 {{- end}}
 </TextureAtlas>
 `))
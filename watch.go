@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// watchInterval is how often the input directory is checked for changes.
+// The input must be unchanged for a whole interval before it is packed
+// again, so that saving many images at once only packs them once.
+const watchInterval = 500 * time.Millisecond
+
+// fileState is what is compared to tell if a file has changed
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// watch packs the input directories and then packs them again whenever files
+// are added, changed or removed, it only returns if it can't watch the inputs.
+// A pack that is still in progress when the input changes is cancelled.
+// The output directories and any other ignored files are not watched.
+func watch(inputDirs, outputDirs, ignore []string, pack func(ctx context.Context) error) error {
+	inputs, err := absPaths(inputDirs)
+	if err != nil {
+		return err
+	}
+	outputs, err := absPaths(outputDirs)
+	if err != nil {
+		return err
+	}
+	// Every pack would write to the input and so start another
+	for _, output := range outputs {
+		if isIgnored(output, inputs) {
+			return fmt.Errorf("Can't watch '%s' as the atlases are written to it, use -out to write them to another directory", output)
+		}
+	}
+	ignored, err := absPaths(ignore)
+	if err != nil {
+		return err
+	}
+	ignored = append(ignored, outputs...)
+
+	cancel := func() {}
+	var done chan struct{}
+	start := func() {
+		cancel()
+		if done != nil {
+			// Wait for the cancelled pack so that
+			// they never write the output at once
+			<-done
+		}
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		done = make(chan struct{})
+		go func(done chan struct{}) {
+			defer close(done)
+			stopTimer := startTimer("Texture packing")
+			err := pack(ctx)
+			if ctx.Err() != nil {
+				log.Printf("Texture packing cancelled")
+				return
+			}
+			stopTimer()
+			if err != nil {
+				log.Print(err)
+			}
+		}(done)
+	}
+
+	log.Printf("Watching '%s' for changes", strings.Join(inputDirs, "', '"))
+	start()
+	snapshot := snapshotDirs(inputDirs, ignored)
+	pending := false
+	tick := time.Tick(watchInterval)
+	for {
+		<-tick
+		current := snapshotDirs(inputDirs, ignored)
+		if !sameSnapshot(snapshot, current) {
+			// Don't let a pack of the old input keep writing
+			// while waiting for the input to stop changing
+			cancel()
+			snapshot = current
+			pending = true
+			continue
+		}
+		if pending {
+			pending = false
+			start()
+		}
+	}
+}
+
+// snapshotDirs records the state of every file in the directories
+func snapshotDirs(dirs []string, ignored []string) map[string]fileState {
+	snapshot := map[string]fileState{}
+	for _, dir := range dirs {
+		snapshotDir(snapshot, dir, ignored)
+	}
+	return snapshot
+}
+
+func snapshotDir(snapshot map[string]fileState, dir string, ignored []string) {
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		// Files may be removed while walking, they
+		// will be missing from the next snapshot
+		if err != nil {
+			return nil
+		}
+		if abs, err := filepath.Abs(path); err == nil && isIgnored(abs, ignored) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			snapshot[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+		}
+		return nil
+	})
+}
+
+// absPaths returns the absolute form of every path,
+// an empty path is the working directory
+func absPaths(paths []string) ([]string, error) {
+	abs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		p, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		abs = append(abs, p)
+	}
+	return abs, nil
+}
+
+func isIgnored(path string, ignored []string) bool {
+	for _, ignore := range ignored {
+		if path == ignore {
+			return true
+		}
+	}
+	return false
+}
+
+func sameSnapshot(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, state := range a {
+		other, ok := b[path]
+		if !ok || other.size != state.size || !other.modTime.Equal(state.modTime) {
+			return false
+		}
+	}
+	return true
+}
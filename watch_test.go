@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchRefusesToWatchTheOutputDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lovepac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	packed := false
+	pack := func(ctx context.Context) error {
+		packed = true
+		return nil
+	}
+
+	err = watch([]string{dir}, []string{filepath.Join(dir, ".")}, nil, pack)
+	if err == nil {
+		t.Errorf("Expected an error as the atlases would be written to the input but got nil")
+	}
+	if packed {
+		t.Errorf("Expected nothing to be packed")
+	}
+}
+
+func TestSnapshotDirsIgnoresTheOutputAndCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lovepac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, file := range []string{"a.png", filepath.Join("build", "atlas-1.png"), "atlas.cache"} {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ignored := []string{filepath.Join(dir, "build"), filepath.Join(dir, "atlas.cache")}
+	snapshot := snapshotDirs([]string{dir}, ignored)
+	if len(snapshot) != 1 {
+		t.Errorf("Expected only 'a.png' to be watched but got %v", snapshot)
+	}
+
+	// Changing a watched file changes the snapshot
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "a.png"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if sameSnapshot(snapshot, snapshotDirs([]string{dir}, ignored)) {
+		t.Errorf("Expected the snapshot to change when 'a.png' was modified")
+	}
+}